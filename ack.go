@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"sync"
+	"time"
+)
+
+// IqStanza extension ids.
+const (
+	iqExtensionSelectiveAck int32 = 12
+	iqExtensionStreamAck    int32 = 13
+)
+
+// sentAck is SelectiveAck waiting for confirmation by the server.
+type sentAck struct {
+	streamId      int32
+	persistentIds []string
+}
+
+// streamAck keeps track of acknowledgements of received messages on MCS connection.
+type streamAck struct {
+	mu           sync.Mutex
+	threshold    int
+	timeout      time.Duration
+	onConfirmed  func([]string)
+	lastReported int32
+	queued       []string
+	pending      []sentAck
+	timer        *time.Timer
+	stopped      bool
+}
+
+func newStreamAck(threshold int, timeout time.Duration, onConfirmed func([]string)) *streamAck {
+	return &streamAck{
+		threshold:   threshold,
+		timeout:     timeout,
+		onConfirmed: onConfirmed,
+	}
+}
+
+// add queues persistent id, and reports whether acks should be sent immediately.
+// Otherwise, flush is called after timeout. Ids added after stop are ignored,
+// they are reported by the next login request.
+func (a *streamAck) add(persistentId string, incomingStreamId int32, flush func()) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopped {
+		return false
+	}
+	a.queued = append(a.queued, persistentId)
+	if len(a.queued) >= a.threshold || int(incomingStreamId-a.lastReported) >= a.threshold {
+		return true
+	}
+	if a.timer == nil {
+		a.timer = time.AfterFunc(a.timeout, flush)
+	}
+	return false
+}
+
+// hasQueued reports whether persistent ids are waiting to be sent.
+func (a *streamAck) hasQueued() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.queued) > 0
+}
+
+// take returns queued persistent ids, and clears them.
+func (a *streamAck) take() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	ids := a.queued
+	a.queued = nil
+	return ids
+}
+
+// sent records SelectiveAck sent with stream id.
+func (a *streamAck) sent(streamId int32, persistentIds []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pending = append(a.pending, sentAck{streamId: streamId, persistentIds: persistentIds})
+}
+
+// reported records incoming stream id notified to the server.
+func (a *streamAck) reported(incomingStreamId int32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lastReported = incomingStreamId
+}
+
+// confirm handles last stream id received by the server.
+func (a *streamAck) confirm(lastStreamIdReceived int32) {
+	if lastStreamIdReceived <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	var confirmed []string
+	n := 0
+	for _, p := range a.pending {
+		if p.streamId <= lastStreamIdReceived {
+			confirmed = append(confirmed, p.persistentIds...)
+		} else {
+			a.pending[n] = p
+			n++
+		}
+	}
+	a.pending = a.pending[:n]
+	a.mu.Unlock()
+
+	if len(confirmed) > 0 && a.onConfirmed != nil {
+		a.onConfirmed(confirmed)
+	}
+}
+
+func (a *streamAck) stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stopped = true
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+}
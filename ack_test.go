@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStreamAckThreshold(t *testing.T) {
+	a := newStreamAck(3, time.Hour, nil)
+	defer a.stop()
+	flush := func() {}
+
+	if a.add("a", 1, flush) || a.add("b", 2, flush) {
+		t.Fatal("acks are flushed before threshold")
+	}
+	if !a.add("c", 3, flush) {
+		t.Fatal("acks are not flushed at threshold of queued ids")
+	}
+	if ids := a.take(); !slices.Equal(ids, []string{"a", "b", "c"}) {
+		t.Fatalf("taken ids = %v", ids)
+	}
+	if ids := a.take(); len(ids) != 0 {
+		t.Fatalf("ids are left after take: %v", ids)
+	}
+
+	// unreported stream ids count even when few messages are queued.
+	a.reported(3)
+	if a.add("d", 5, flush) {
+		t.Fatal("acks are flushed before threshold of stream ids")
+	}
+	if !a.add("e", 6, flush) {
+		t.Fatal("acks are not flushed at threshold of stream ids")
+	}
+}
+
+func TestStreamAckTimeout(t *testing.T) {
+	a := newStreamAck(10, 10*time.Millisecond, nil)
+	defer a.stop()
+
+	flushed := make(chan struct{}, 1)
+	if a.add("a", 1, func() { flushed <- struct{}{} }) {
+		t.Fatal("acks are flushed before threshold")
+	}
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("acks are not flushed after timeout")
+	}
+}
+
+func TestStreamAckConfirm(t *testing.T) {
+	var confirmed [][]string
+	a := newStreamAck(10, time.Hour, func(ids []string) {
+		confirmed = append(confirmed, ids)
+	})
+	defer a.stop()
+
+	a.sent(3, []string{"a", "b"})
+	a.sent(5, []string{"c"})
+
+	a.confirm(0)
+	a.confirm(4)
+	a.confirm(4)
+	a.confirm(5)
+
+	want := [][]string{{"a", "b"}, {"c"}}
+	if !slices.EqualFunc(confirmed, want, slices.Equal) {
+		t.Fatalf("confirmed = %v, want %v", confirmed, want)
+	}
+}
+
+func TestStreamAckStop(t *testing.T) {
+	a := newStreamAck(10, 10*time.Millisecond, nil)
+
+	flushed := make(chan struct{}, 2)
+	flush := func() { flushed <- struct{}{} }
+	a.add("a", 1, flush)
+	a.stop()
+	a.add("b", 2, flush)
+	select {
+	case <-flushed:
+		t.Fatal("acks are flushed after stop")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	pb "github.com/crow-misia/go-push-receiver/pb/mcs"
+	"github.com/pkg/errors"
+)
+
+// appClient returns client of the additional app, which shares the HTTP client and endpoints.
+func (c *Client) appClient(config *Config) *Client {
+	vapidKey := config.VapidKey
+	if len(vapidKey) == 0 {
+		vapidKey = fcmServerKey
+	}
+	return &Client{
+		apiKey:     config.ApiKey,
+		projectID:  config.ProjectID,
+		appID:      config.AppID,
+		vapidKey:   vapidKey,
+		logger:     c.logger.With("appID", config.AppID),
+		httpClient: c.httpClient,
+		endpoints:  c.endpoints,
+	}
+}
+
+// appConfig returns config of the additional app, or error when the app is removed from options.
+func (c *Client) appConfig(appID string) (*Config, error) {
+	for _, config := range c.apps {
+		if config.AppID == appID {
+			return config, nil
+		}
+	}
+	return nil, errors.Errorf("app %s is not configured", appID)
+}
+
+// validateApps checks that additional apps have distinct app ids.
+func (c *Client) validateApps() error {
+	seen := map[string]bool{c.appID: true}
+	for _, config := range c.apps {
+		if config == nil || len(config.AppID) == 0 {
+			return errors.New("additional app has no app id")
+		}
+		if seen[config.AppID] {
+			return errors.Errorf("duplicated app id %s", config.AppID)
+		}
+		seen[config.AppID] = true
+	}
+	return nil
+}
+
+// refreshAppTokens rotates registration tokens of additional apps which are due.
+// Failed apps keep their tokens, and the last error is returned.
+func (c *Client) refreshAppTokens(ctx context.Context) error {
+	old := c.credentials()
+	if old == nil || len(old.Apps) == 0 {
+		return nil
+	}
+
+	var failed error
+	apps := make([]*FCMCredentials, 0, len(old.Apps))
+	for _, app := range old.Apps {
+		if len(app.FID) == 0 || !c.tokenRefreshDue(app) {
+			apps = append(apps, app)
+			continue
+		}
+		refreshed, err := c.refreshAppToken(ctx, app)
+		if err != nil {
+			failed = errors.Wrapf(err, "refresh app %s", app.AppID)
+			apps = append(apps, app)
+			continue
+		}
+		apps = append(apps, refreshed)
+	}
+	if slices.Equal(apps, old.Apps) {
+		return failed
+	}
+
+	creds := *old
+	creds.Apps = apps
+	if !c.swapCredentials(old, &creds) {
+		// credentials are updated while refreshing, apps are refreshed again.
+		return failed
+	}
+	c.saveCredentials(ctx, &creds)
+	c.emit(ctx, &UpdateCredentialsEvent{Credentials: &creds})
+	return failed
+}
+
+// refreshAppToken rotates registration token of the additional app.
+func (c *Client) refreshAppToken(ctx context.Context, old *FCMCredentials) (*FCMCredentials, error) {
+	config, err := c.appConfig(old.AppID)
+	if err != nil {
+		// the app is removed from options, and dropped by registerApps.
+		return old, nil
+	}
+	app := c.appClient(config)
+	creds := *old
+	if _, err := app.ensureAuthToken(ctx, &creds); err != nil {
+		return nil, errors.Wrap(err, "generate auth token")
+	}
+	token, err := app.updateFCM(ctx, &creds)
+	if err != nil {
+		return nil, err
+	}
+	creds.Token = token
+	creds.TokenUpdatedAt = time.Now()
+	return &creds, nil
+}
+
+// registerApps registers additional apps which have no registration with the device checkin of the credentials.
+// Apps failed to register are reported by AppError, and retried on next connect.
+// It returns error only when ctx is done.
+func (c *Client) registerApps(ctx context.Context) error {
+	if len(c.apps) == 0 {
+		return nil
+	}
+	old := c.credentials()
+
+	var registered []*FCMCredentials
+	for _, config := range c.apps {
+		if app := old.app(config.AppID); app != nil && app.AndroidID == old.AndroidID {
+			registered = append(registered, app)
+			continue
+		}
+		app, err := c.registerApp(ctx, old, config)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			c.logger.Warn("failed to register app", "appID", config.AppID, slog.Any("error", err))
+			c.emit(ctx, &AppError{AppID: config.AppID, ErrorObj: err})
+			continue
+		}
+		registered = append(registered, app)
+	}
+	if slices.Equal(registered, old.Apps) {
+		return nil
+	}
+
+	creds := *old
+	creds.Apps = registered
+	if !c.swapCredentials(old, &creds) {
+		return nil
+	}
+	c.saveCredentials(ctx, &creds)
+	c.emit(ctx, &UpdateCredentialsEvent{Credentials: &creds})
+	return nil
+}
+
+// registerApp registers the app with the device checkin of the credentials.
+func (c *Client) registerApp(ctx context.Context, creds *FCMCredentials, config *Config) (*FCMCredentials, error) {
+	release, err := c.acquireConnect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	app := c.appClient(config)
+	register, err := app.doRegister(ctx, creds.AndroidID, creds.SecurityToken)
+	if err != nil {
+		return nil, err
+	}
+	install, err := app.installFCM(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return app.registerFCM(ctx, register, install)
+}
+
+// app returns credentials of the additional app, or nil when the app is not registered.
+func (c *FCMCredentials) app(appID string) *FCMCredentials {
+	for _, app := range c.Apps {
+		if app.AppID == appID {
+			return app
+		}
+	}
+	return nil
+}
+
+// route returns credentials of the app which the message is sent to.
+// Messages are routed by subtype, which is the app id given at GCM registration, and then by registration token.
+// The primary credentials are returned when no additional app matches.
+func (c *FCMCredentials) route(data *pb.DataMessageStanza) *FCMCredentials {
+	if len(c.Apps) == 0 {
+		return c
+	}
+	if subtype, err := findByKey(data.GetAppData(), "subtype"); err == nil {
+		if app := c.app(subtype.GetValue()); app != nil {
+			return app
+		}
+	}
+	if to := data.GetTo(); len(to) > 0 {
+		for _, app := range c.Apps {
+			if app.Token == to {
+				return app
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegisterAppsPartialFailure(t *testing.T) {
+	client := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.URL.String() == registerURL:
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("token=gcm-token")),
+			}, nil
+		case strings.HasSuffix(req.URL.Path, "/installations"):
+			return jsonResponse(http.StatusOK, `{"fid":"fid","refreshToken":"refresh","authToken":{"token":"auth","expiresIn":"604800s"}}`), nil
+		case strings.Contains(req.URL.Path, "/projects/broken/"):
+			return jsonResponse(http.StatusInternalServerError, "{}"), nil
+		default:
+			return jsonResponse(http.StatusOK, `{"token":"app-token"}`), nil
+		}
+	})
+
+	creds := &FCMCredentials{AppID: "primary", AndroidID: 1, SecurityToken: 2, Token: "token"}
+	c := New(&Config{ProjectID: "project", AppID: "primary"},
+		WithHTTPClient(client),
+		WithCreds(creds),
+		WithApps(
+			&Config{ProjectID: "broken", AppID: "bad"},
+			&Config{ProjectID: "good", AppID: "good"},
+		),
+	)
+	nextEvent := queuedEvents(t, c)
+
+	if err := c.registerApps(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	apps := c.credentials().Apps
+	if len(apps) != 1 || apps[0].AppID != "good" || apps[0].Token != "app-token" || apps[0].AndroidID != creds.AndroidID {
+		t.Fatalf("registered apps = %+v", apps)
+	}
+
+	appErr, ok := nextEvent().(*AppError)
+	if !ok || appErr.AppID != "bad" || appErr.ErrorObj == nil {
+		t.Fatalf("event = %#v, want AppError of bad", appErr)
+	}
+	updated, ok := nextEvent().(*UpdateCredentialsEvent)
+	if !ok || len(updated.Credentials.Apps) != 1 {
+		t.Fatalf("event = %#v, want UpdateCredentialsEvent", updated)
+	}
+}
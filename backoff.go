@@ -59,6 +59,11 @@ func (b *Backoff) duration() time.Duration {
 	return time.Duration(duration)
 }
 
+// maxDuration returns maximum duration of backoff.
+func (b *Backoff) maxDuration() time.Duration {
+	return time.Duration(b.max)
+}
+
 func (b *Backoff) reset() {
 	b.attempts = 0
 }
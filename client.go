@@ -15,6 +15,8 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -31,25 +33,71 @@ type Client struct {
 	projectID            string
 	appID                string
 	vapidKey             string
+	apps                 []*Config
 	logger               *slog.Logger
 	httpClient           httpClient
 	tlsConfig            *tls.Config
 	creds                *FCMCredentials
+	credsMu              sync.Mutex
+	credentialStore      CredentialStore
+	credsLoaded          bool
+	tokenRefreshPeriod   time.Duration
 	dialer               *net.Dialer
+	dialContext          DialContextFunc
+	endpoints            Endpoints
+	lastMTalk            string
+	connectLimiter       chan struct{}
+	mtalkMu              sync.Mutex
 	backoff              *Backoff
 	heartbeat            *Heartbeat
 	receivedPersistentID []string
+	persistentIDStore    PersistentIDStore
+	manualAck            bool
+	iterAck              bool
+	maxFrameSize         int
+	ackThreshold         int
+	ackTimeout           time.Duration
 	retryDisabled        bool
+	optionErr            error
+	handler              Handler
+	handlerWorkers       int
+	eventQueueSize       int
+	overflowPolicy       OverflowPolicy
+	spillFilename        string
+	queue                *eventQueue
+	eventCounters        eventCounters
 	Events               chan Event
 }
 
 // New returns a new FCM push receive client instance.
+// Invalid options are logged, and Subscribe and Unregister fail with the error.
+// Use NewClient to handle the error at creation.
 func New(config *Config, options ...ClientOption) *Client {
+	c := newClient(config, options...)
+	if c.optionErr != nil {
+		c.logger.Error("invalid client options", slog.Any("error", c.optionErr))
+	}
+	return c
+}
+
+// NewClient returns a new FCM push receive client instance, or error when options are invalid.
+func NewClient(config *Config, options ...ClientOption) (*Client, error) {
+	c := newClient(config, options...)
+	if c.optionErr != nil {
+		return nil, c.optionErr
+	}
+	return c, nil
+}
+
+func newClient(config *Config, options ...ClientOption) *Client {
 	c := &Client{
 		apiKey:    config.ApiKey,
 		projectID: config.ProjectID,
 		appID:     config.AppID,
 		vapidKey:  config.VapidKey,
+
+		tokenRefreshPeriod: defaultTokenRefreshPeriod * 24 * time.Hour,
+		overflowPolicy:     OverflowDropOldest,
 	}
 
 	for _, option := range options {
@@ -61,16 +109,43 @@ func New(config *Config, options ...ClientOption) *Client {
 
 	c.logger.Debug("Config", "apiKey", c.apiKey, "projectID", c.projectID, "appID", c.appID, "vapidKey", c.vapidKey)
 
+	c.optionErr = c.validate()
 	return c
 }
 
+// validate checks the options.
+func (c *Client) validate() error {
+	if err := c.endpoints.validate(); err != nil {
+		return err
+	}
+	if err := c.validateApps(); err != nil {
+		return err
+	}
+	if c.overflowPolicy == OverflowSpill && len(c.spillFilename) == 0 {
+		return errors.New("spill file is required for OverflowSpill")
+	}
+	return nil
+}
+
 func (c *Client) post(ctx context.Context, url string, body io.Reader, headerSetter func(*http.Header)) (*http.Response, error) {
+	return c.request(ctx, http.MethodPost, url, body, headerSetter)
+}
+
+func (c *Client) patch(ctx context.Context, url string, body io.Reader, headerSetter func(*http.Header)) (*http.Response, error) {
+	return c.request(ctx, http.MethodPatch, url, body, headerSetter)
+}
+
+func (c *Client) delete(ctx context.Context, url string, headerSetter func(*http.Header)) (*http.Response, error) {
+	return c.request(ctx, http.MethodDelete, url, nil, headerSetter)
+}
+
+func (c *Client) request(ctx context.Context, method string, url string, body io.Reader, headerSetter func(*http.Header)) (*http.Response, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return nil, errors.Wrap(err, "create post request error")
+		return nil, errors.Wrapf(err, "create %s request error", strings.ToLower(method))
 	}
 	headerSetter(&req.Header)
 
@@ -88,6 +163,15 @@ func (c *Client) setDefaultOptions() {
 			WithClientInterval(defaultHeartbeatPeriod * time.Minute),
 		)
 	}
+	if c.maxFrameSize <= 0 {
+		c.maxFrameSize = defaultMaxFrameSize
+	}
+	if c.ackThreshold <= 0 {
+		c.ackThreshold = defaultAckThreshold
+	}
+	if c.ackTimeout <= 0 {
+		c.ackTimeout = defaultAckTimeout * time.Second
+	}
 	if c.tlsConfig == nil {
 		c.tlsConfig = &tls.Config{
 			InsecureSkipVerify: false,
@@ -104,6 +188,7 @@ func (c *Client) setDefaultOptions() {
 	if c.httpClient == nil {
 		c.httpClient = &http.Client{
 			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
 				TLSClientConfig: c.tlsConfig,
 			},
 		}
@@ -111,6 +196,22 @@ func (c *Client) setDefaultOptions() {
 	if c.logger == nil {
 		c.logger = slog.New(noOpHandler{})
 	}
+	if c.persistentIDStore == nil {
+		c.persistentIDStore = NewMemoryPersistentIDStore(defaultPersistentIDRetention * time.Hour)
+	}
+	for _, id := range c.receivedPersistentID {
+		if err := c.persistentIDStore.Acked(context.Background(), id); err != nil {
+			c.logger.Error("failed to store persistent id", "persistentID", id, slog.Any("error", err))
+		}
+	}
+	c.receivedPersistentID = nil
+	c.endpoints = c.endpoints.withDefaults()
+	if c.handlerWorkers <= 0 {
+		c.handlerWorkers = defaultHandlerWorkers
+	}
+	if c.eventQueueSize <= 0 {
+		c.eventQueueSize = defaultEventQueueSize
+	}
 	if c.Events == nil {
 		c.Events = make(chan Event, 50)
 	}
@@ -119,6 +220,19 @@ func (c *Client) setDefaultOptions() {
 	}
 }
 
+// acquireConnect waits for a slot of concurrent registrations and connects shared by Manager.
+func (c *Client) acquireConnect(ctx context.Context) (func(), error) {
+	if c.connectLimiter == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.connectLimiter <- struct{}{}:
+		return func() { <-c.connectLimiter }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 func closeResponse(logger *slog.Logger, res *http.Response) {
 	if res == nil || res.Body == nil {
 		return
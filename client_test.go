@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// failingHTTPClient fails the test on any request.
+type failingHTTPClient struct {
+	t *testing.T
+}
+
+func (c failingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.t.Errorf("unexpected request: %s %s", req.Method, req.URL)
+	return nil, errors.New("unexpected request")
+}
+
+// queuedEvents makes the client queue events without Subscribe, and returns function to take them in order.
+func queuedEvents(t *testing.T, c *Client) func() Event {
+	t.Helper()
+	q, err := newEventQueue(10, OverflowBlock, "", c.logger, &c.eventCounters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.queue = q
+	return func() Event {
+		event, _ := q.pop()
+		return event
+	}
+}
+
+func TestNewClientInvalidEndpoints(t *testing.T) {
+	endpoints := Endpoints{
+		Checkin:  "htps://checkin.example.com",
+		Register: "https://register.example.com/register",
+		MTalk:    "mtalk.example.com:5228",
+	}
+	if _, err := NewClient(&Config{}, WithEndpoints(endpoints)); err == nil {
+		t.Fatal("NewClient accepted invalid endpoint")
+	}
+
+	c := New(&Config{}, WithEndpoints(endpoints), WithHTTPClient(failingHTTPClient{t}))
+	if c.endpoints.Checkin != endpoints.Checkin || c.endpoints.Register != endpoints.Register || c.endpoints.MTalk != endpoints.MTalk {
+		t.Fatalf("endpoints are replaced: %+v", c.endpoints)
+	}
+
+	c.Subscribe(context.Background())
+	var events []Event
+	for event := range c.Events {
+		events = append(events, event)
+	}
+	if len(events) != 1 {
+		t.Fatalf("events = %v, want only OptionError", events)
+	}
+	if e, ok := events[0].(*OptionError); !ok || e.ErrorObj == nil {
+		t.Fatalf("event = %#v, want OptionError", events[0])
+	}
+
+	if _, err := c.Unregister(context.Background()); err == nil {
+		t.Fatal("Unregister succeeded with invalid endpoint")
+	}
+}
+
+func TestSubscribeInvalidSpillFile(t *testing.T) {
+	c := New(&Config{}, WithEventQueue(10, OverflowSpill), WithHTTPClient(failingHTTPClient{t}))
+	c.Subscribe(context.Background())
+
+	event, ok := <-c.Events
+	if e, isOptionErr := event.(*OptionError); !ok || !isOptionErr || e.ErrorObj == nil {
+		t.Fatalf("event = %#v, want OptionError", event)
+	}
+	if _, ok := <-c.Events; ok {
+		t.Fatal("Events is not closed")
+	}
+}
@@ -18,7 +18,11 @@ const (
 
 	fcmServerKey = "BDOU99-h67HcA6JeFXHbSNMu7e2yNNu3RzoMj8TM4W88jITfq7ZmPvIM1Iv-4_l2LxQcYwhqby2xGpWwzjfAnG4"
 
-	mtalkServer   = "mtalk.google.com:5228"
+	mtalkServer             = "mtalk.google.com:5228"
+	mtalkServerFallback443  = "mtalk.google.com:443"
+	mtalkServerFallback5229 = "mtalk.google.com:5229"
+	mtalkServerFallback5230 = "mtalk.google.com:5230"
+
 	mcsDomain     = "mcs.android.com"
 	chromeVersion = "63.0.3234.0"
 	fcmVersion    = 41
@@ -33,6 +37,13 @@ const (
 	tagPacketLen     = 1
 	sizePacketLenMin = 1
 	sizePacketLenMax = 5
+
+	// size of read buffer of MCS connection.
+	frameReaderBufferSize = 4 * 1024
+	// initial size of pooled frame buffer.
+	framePoolBufferSize = 4 * 1024
+	// maximum size of frame buffer returned to pool.
+	framePoolMaxBufferSize = 64 * 1024
 )
 
 // Default values
@@ -51,4 +62,52 @@ const (
 
 	// Default Heartbeat period (minutes)
 	defaultHeartbeatPeriod = 10
+
+	// Minimum Heartbeat period (minutes)
+	minHeartbeatInterval = 1
+
+	// Default step of adaptive heartbeat period (minutes)
+	defaultAdaptiveStep = 2
+
+	// Default max adaptive heartbeat period (minutes)
+	defaultAdaptiveMax = 28
+
+	// Default period of registration token refresh (days)
+	defaultTokenRefreshPeriod = 7
+
+	// Retry interval when registration token refresh failed (minutes)
+	tokenRefreshRetryInterval = 60
+
+	// Buffer before auth token expiration to generate new one (minutes)
+	authTokenExpirationBuffer = 60
+
+	// Default retention of acknowledged persistent ids to suppress duplicated messages (hours)
+	defaultPersistentIDRetention = 24
+
+	// Minimum number of records in persistent id file before compaction
+	persistentIDCompactionMin = 1000
+
+	// Default max number of concurrent registrations and connects of Manager
+	defaultMaxConcurrentConnects = 4
+
+	// Default buffer size of merged event channel of Manager
+	defaultManagerEventBuffer = 100
+
+	// Default size of the event queue
+	defaultEventQueueSize = 100
+
+	// Default number of workers calling Handler
+	defaultHandlerWorkers = 4
+
+	// Default max MCS frame size (bytes)
+	defaultMaxFrameSize = 4 * 1024 * 1024
+
+	// Default number of unacknowledged messages before sending stream ack
+	defaultAckThreshold = 10
+
+	// Default timeout before sending stream ack (seconds)
+	defaultAckTimeout = 5
+
+	// Write timeout of queued acks on close (seconds)
+	ackFlushTimeout = 1
 )
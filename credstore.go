@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// CredentialStore loads and saves credentials.
+type CredentialStore interface {
+	// Load returns stored credentials, or nil when no credentials are stored.
+	Load(ctx context.Context) (*FCMCredentials, error)
+	// Save stores credentials.
+	Save(ctx context.Context, creds *FCMCredentials) error
+	// Delete removes stored credentials.
+	Delete(ctx context.Context) error
+}
+
+// FileCredentialStore stores credentials in a JSON file.
+type FileCredentialStore struct {
+	filename string
+	mu       sync.Mutex
+}
+
+// NewFileCredentialStore creates FileCredentialStore instance.
+func NewFileCredentialStore(filename string) *FileCredentialStore {
+	return &FileCredentialStore{
+		filename: filename,
+	}
+}
+
+// Load returns credentials in the file.
+func (s *FileCredentialStore) Load(_ context.Context) (*FCMCredentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read credentials file")
+	}
+
+	creds := &FCMCredentials{}
+	if err := json.Unmarshal(data, creds); err != nil {
+		return nil, errors.Wrap(err, "unmarshal credentials")
+	}
+	return creds, nil
+}
+
+// Save writes credentials to the file atomically.
+func (s *FileCredentialStore) Save(_ context.Context, creds *FCMCredentials) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal credentials")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return writeFileAtomic(s.filename, data)
+}
+
+// Delete removes the file.
+func (s *FileCredentialStore) Delete(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.filename); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove credentials file")
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to temporary file, and renames it to filename.
+func writeFileAtomic(filename string, data []byte) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "create temporary file")
+	}
+	tmpName := f.Name()
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	if err = f.Chmod(0600); err != nil {
+		return errors.Wrap(err, "change mode of temporary file")
+	}
+	if _, err = f.Write(data); err != nil {
+		return errors.Wrap(err, "write temporary file")
+	}
+	if err = f.Sync(); err != nil {
+		return errors.Wrap(err, "sync temporary file")
+	}
+	if err = f.Close(); err != nil {
+		return errors.Wrap(err, "close temporary file")
+	}
+	if err = os.Rename(tmpName, filename); err != nil {
+		return errors.Wrap(err, "rename temporary file")
+	}
+	return nil
+}
+
+// MemoryCredentialStore stores credentials in memory.
+type MemoryCredentialStore struct {
+	creds *FCMCredentials
+	mu    sync.Mutex
+}
+
+// NewMemoryCredentialStore creates MemoryCredentialStore instance.
+func NewMemoryCredentialStore() *MemoryCredentialStore {
+	return &MemoryCredentialStore{}
+}
+
+// Load returns stored credentials.
+func (s *MemoryCredentialStore) Load(_ context.Context) (*FCMCredentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.creds, nil
+}
+
+// Save stores credentials.
+func (s *MemoryCredentialStore) Save(_ context.Context, creds *FCMCredentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.creds = creds
+	return nil
+}
+
+// Delete removes stored credentials.
+func (s *MemoryCredentialStore) Delete(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.creds = nil
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"crypto/ecdh"
 	"crypto/rand"
 	"encoding/base64"
+	"strings"
 
 	pb "github.com/crow-misia/go-push-receiver/pb/mcs"
 	ece "github.com/crow-misia/http-ece"
@@ -42,16 +43,25 @@ func decryptData(data *pb.DataMessageStanza, creds *FCMCredentials) (*MessageEve
 	var bytes []byte
 	var err error
 
-	contentEncoding, err := findByKey(data.GetAppData(), "content-encoding")
-	if err == nil && *contentEncoding.Value == "aes128gcm" {
+	contentEncoding, _ := findByKey(data.GetAppData(), "content-encoding")
+	_, cryptoKeyErr := findByKey(data.GetAppData(), "crypto-key")
+	_, encryptionErr := findByKey(data.GetAppData(), "encryption")
+	switch {
+	case contentEncoding != nil && contentEncoding.GetValue() == "aes128gcm":
 		bytes, err = decryptDataV1(data, creds)
-	} else {
+	case contentEncoding != nil || cryptoKeyErr == nil || encryptionErr == nil:
+		// any of encryption headers marks the payload as encrypted, and missing ones fail decryption.
 		bytes, err = decryptDataLegacy(data, creds)
+	default:
+		// data message without encryption is delivered as is, payload may be only in app data.
+		return newMessageEvent(data, data.GetRawData()), nil
 	}
 	if err != nil {
 		return nil, errors.Wrap(err, "decrypt HTTP-ECE data")
 	}
-	return newMessageEvent(data, bytes), nil
+	event := newMessageEvent(data, bytes)
+	event.Encrypted = true
+	return event, nil
 }
 
 func decryptDataLegacy(data *pb.DataMessageStanza, creds *FCMCredentials) ([]byte, error) {
@@ -61,8 +71,11 @@ func decryptDataLegacy(data *pb.DataMessageStanza, creds *FCMCredentials) ([]byt
 	if err != nil {
 		return nil, errors.Wrap(err, "dh is not provided")
 	}
-
-	cryptoKey, err := base64.URLEncoding.DecodeString(cryptoKeyData.GetValue()[3:])
+	dh, err := findParam(cryptoKeyData.GetValue(), "dh")
+	if err != nil {
+		return nil, errors.Wrap(err, "dh is not provided")
+	}
+	cryptoKey, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(dh, "="))
 	if err != nil {
 		return nil, errors.Wrap(err, "decode decrypt data")
 	}
@@ -71,7 +84,11 @@ func decryptDataLegacy(data *pb.DataMessageStanza, creds *FCMCredentials) ([]byt
 	if err != nil {
 		return nil, errors.Wrap(err, "salt is not provided")
 	}
-	salt, err := base64.URLEncoding.DecodeString(saltData.GetValue()[5:])
+	saltParam, err := findParam(saltData.GetValue(), "salt")
+	if err != nil {
+		return nil, errors.Wrap(err, "salt is not provided")
+	}
+	salt, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(saltParam, "="))
 	if err != nil {
 		return nil, errors.Wrap(err, "decode salt")
 	}
@@ -113,9 +130,20 @@ func generateAuthSecret() ([]byte, error) {
 	return salt, err
 }
 
+// findParam returns value of the parameter in header value such as "dh=...;p256ecdsa=...".
+func findParam(value string, name string) (string, error) {
+	for _, param := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		key, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && strings.EqualFold(key, name) {
+			return strings.Trim(v, `"`), nil
+		}
+	}
+	return "", ErrNotFoundInAppData
+}
+
 func findByKey(data []*pb.AppData, key string) (*pb.AppData, error) {
 	for _, data := range data {
-		if *data.Key == key {
+		if data.GetKey() == key {
 			return data, nil
 		}
 	}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"testing"
+
+	pb "github.com/crow-misia/go-push-receiver/pb/mcs"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDecryptData(t *testing.T) {
+	creds := &FCMCredentials{}
+	if err := creds.appendCryptoInfo(); err != nil {
+		t.Fatal(err)
+	}
+
+	appData := func(kv ...string) []*pb.AppData {
+		var data []*pb.AppData
+		for i := 0; i < len(kv); i += 2 {
+			data = append(data, &pb.AppData{Key: proto.String(kv[i]), Value: proto.String(kv[i+1])})
+		}
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		data    *pb.DataMessageStanza
+		payload string
+		err     bool
+	}{
+		{
+			name:    "plaintext",
+			data:    &pb.DataMessageStanza{RawData: []byte("hello")},
+			payload: "hello",
+		},
+		{
+			name: "app data only",
+			data: &pb.DataMessageStanza{AppData: appData("title", "hello")},
+		},
+		{
+			name: "malformed crypto-key",
+			data: &pb.DataMessageStanza{
+				RawData: []byte("encrypted"),
+				AppData: appData("crypto-key", "dh=!!!", "encryption", "salt=c2FsdA"),
+			},
+			err: true,
+		},
+		{
+			name: "crypto-key without dh",
+			data: &pb.DataMessageStanza{
+				RawData: []byte("encrypted"),
+				AppData: appData("crypto-key", "p256ecdsa=key", "encryption", "salt=c2FsdA"),
+			},
+			err: true,
+		},
+		{
+			name: "encryption only",
+			data: &pb.DataMessageStanza{
+				RawData: []byte("encrypted"),
+				AppData: appData("encryption", "salt=c2FsdA"),
+			},
+			err: true,
+		},
+		{
+			name: "content-encoding only",
+			data: &pb.DataMessageStanza{
+				RawData: []byte("encrypted"),
+				AppData: appData("content-encoding", "aesgcm"),
+			},
+			err: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := decryptData(tt.data, creds)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("encrypted payload is delivered as is: %q", event.Data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Encrypted {
+				t.Error("plaintext message is marked as encrypted")
+			}
+			if string(event.Data) != tt.payload {
+				t.Errorf("data = %q, want %q", event.Data, tt.payload)
+			}
+			if len(event.AppData) != len(tt.data.GetAppData()) {
+				t.Errorf("app data = %v", event.AppData)
+			}
+		})
+	}
+}
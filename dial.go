@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// DialContextFunc is function to dial network connection.
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// dialMTalk connects to MCS server candidates in turn, and returns the connection with its address.
+// The address which worked last time is tried first.
+func (c *Client) dialMTalk(ctx context.Context) (*tls.Conn, string, error) {
+	var lastErr error
+	for _, addr := range c.endpoints.mtalkCandidates(c.preferredMTalk()) {
+		conn, err := c.dialMCS(ctx, addr)
+		if err == nil {
+			return conn, addr, nil
+		}
+		c.logger.Debug("failed to connect MCS server", "addr", addr, slog.Any("error", err))
+		lastErr = errors.Wrapf(err, "dial %s", addr)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, "", lastErr
+}
+
+// preferredMTalk returns MCS server address which worked last time.
+func (c *Client) preferredMTalk() string {
+	c.mtalkMu.Lock()
+	defer c.mtalkMu.Unlock()
+	return c.lastMTalk
+}
+
+// rememberMTalk records MCS server address which worked.
+func (c *Client) rememberMTalk(addr string) {
+	c.mtalkMu.Lock()
+	defer c.mtalkMu.Unlock()
+	c.lastMTalk = addr
+}
+
+// dialMCS connects to MCS server, and performs TLS handshake.
+// Without custom dial function, the connection goes through the proxy of the standard proxy environment variables.
+func (c *Client) dialMCS(ctx context.Context, addr string) (*tls.Conn, error) {
+	if c.dialer.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.dialer.Timeout)
+		defer cancel()
+	}
+
+	conn, err := c.dialRaw(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	config := c.tlsConfig.Clone()
+	if len(config.ServerName) == 0 {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			_ = conn.Close()
+			return nil, errors.Wrap(err, "split address")
+		}
+		config.ServerName = host
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "TLS handshake")
+	}
+	return tlsConn, nil
+}
+
+func (c *Client) dialRaw(ctx context.Context, addr string) (net.Conn, error) {
+	if c.dialContext != nil {
+		return c.dialContext(ctx, "tcp", addr)
+	}
+
+	proxyURL, err := proxyForAddr(addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve proxy")
+	}
+	if proxyURL != nil {
+		c.logger.Debug("connect through proxy", "proxy", proxyURL.Redacted(), "addr", addr)
+		return dialProxy(ctx, c.dialer.DialContext, proxyURL, addr)
+	}
+	return c.dialer.DialContext(ctx, "tcp", addr)
+}
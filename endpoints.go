@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Endpoints is service endpoints used by the client.
+// Empty fields are filled with the default endpoints.
+type Endpoints struct {
+	// Checkin is URL of GCM checkin.
+	Checkin string `json:"checkin"`
+	// Register is URL of GCM register.
+	Register string `json:"register"`
+	// FirebaseInstallation is base URL of Firebase installations API.
+	FirebaseInstallation string `json:"firebaseInstallation"`
+	// FirebaseRegistration is base URL of FCM registrations API.
+	FirebaseRegistration string `json:"firebaseRegistration"`
+	// MTalk is host:port of MCS server.
+	MTalk string `json:"mtalk"`
+	// MTalkFallbacks is host:port list of MCS server tried in order when MTalk is unreachable.
+	MTalkFallbacks []string `json:"mtalkFallbacks"`
+}
+
+// DefaultEndpoints returns endpoints of Google services.
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		Checkin:              checkinURL,
+		Register:             registerURL,
+		FirebaseInstallation: firebaseInstallationURL,
+		FirebaseRegistration: firebaseRegistrationURL,
+		MTalk:                mtalkServer,
+		MTalkFallbacks:       []string{mtalkServerFallback443, mtalkServerFallback5229, mtalkServerFallback5230},
+	}
+}
+
+// withDefaults returns endpoints whose empty fields are filled with the default endpoints.
+func (e Endpoints) withDefaults() Endpoints {
+	defaults := DefaultEndpoints()
+	if len(e.Checkin) == 0 {
+		e.Checkin = defaults.Checkin
+	}
+	if len(e.Register) == 0 {
+		e.Register = defaults.Register
+	}
+	if len(e.FirebaseInstallation) == 0 {
+		e.FirebaseInstallation = defaults.FirebaseInstallation
+	}
+	if len(e.FirebaseRegistration) == 0 {
+		e.FirebaseRegistration = defaults.FirebaseRegistration
+	}
+	if len(e.MTalk) == 0 {
+		e.MTalk = defaults.MTalk
+		// fallbacks of Google are used only with default MCS server.
+		if e.MTalkFallbacks == nil {
+			e.MTalkFallbacks = defaults.MTalkFallbacks
+		}
+	}
+
+	// base URLs are joined with paths.
+	if !strings.HasSuffix(e.FirebaseInstallation, "/") {
+		e.FirebaseInstallation += "/"
+	}
+	if !strings.HasSuffix(e.FirebaseRegistration, "/") {
+		e.FirebaseRegistration += "/"
+	}
+	return e
+}
+
+// validate checks all endpoints are valid.
+func (e Endpoints) validate() error {
+	urls := []struct {
+		name  string
+		value string
+	}{
+		{"checkin", e.Checkin},
+		{"register", e.Register},
+		{"firebase installation", e.FirebaseInstallation},
+		{"firebase registration", e.FirebaseRegistration},
+	}
+	for _, u := range urls {
+		if err := validateEndpointURL(u.value); err != nil {
+			return errors.Wrapf(err, "invalid %s endpoint", u.name)
+		}
+	}
+	if err := validateHostPort(e.MTalk); err != nil {
+		return errors.Wrap(err, "invalid mtalk endpoint")
+	}
+	for _, addr := range e.MTalkFallbacks {
+		if err := validateHostPort(addr); err != nil {
+			return errors.Wrap(err, "invalid mtalk fallback endpoint")
+		}
+	}
+	return nil
+}
+
+// mtalkCandidates returns MCS server addresses in order to try, preferred address comes first.
+func (e Endpoints) mtalkCandidates(preferred string) []string {
+	candidates := make([]string, 0, len(e.MTalkFallbacks)+1)
+	if len(preferred) > 0 {
+		candidates = append(candidates, preferred)
+	}
+	for _, addr := range append([]string{e.MTalk}, e.MTalkFallbacks...) {
+		if !slices.Contains(candidates, addr) {
+			candidates = append(candidates, addr)
+		}
+	}
+	return candidates
+}
+
+func validateEndpointURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return errors.Errorf("unsupported scheme %q: %s", u.Scheme, value)
+	}
+	if len(u.Host) == 0 {
+		return errors.Errorf("host is empty: %s", value)
+	}
+	return nil
+}
+
+func validateHostPort(value string) error {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return err
+	}
+	if len(host) == 0 {
+		return errors.Errorf("host is empty: %s", value)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return errors.Errorf("invalid port: %s", value)
+	}
+	return nil
+}
@@ -7,7 +7,11 @@
 
 package pushreceiver
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
 
 // ErrGcmAuthorization is authorization error of GCM.
 var ErrGcmAuthorization = errors.New("GCM authorization error")
@@ -17,3 +21,111 @@ var ErrFcmNotEnoughData = errors.New("data Enough from FCM")
 
 // ErrNotFoundInAppData is error that key not found in app data.
 var ErrNotFoundInAppData = errors.New("key not found")
+
+// ErrNotRegistered is error that the client has no credentials.
+var ErrNotRegistered = errors.New("not registered")
+
+// ErrMissingInstallation is error that credentials have no Firebase installation.
+var ErrMissingInstallation = errors.New("firebase installation not found in credentials")
+
+// ErrFrameTooLarge is error that MCS frame exceeds maximum frame size.
+var ErrFrameTooLarge = errors.New("MCS frame too large")
+
+// ErrServerClosed is error that the server closed MCS connection.
+var ErrServerClosed = errors.New("MCS connection closed by server")
+
+// StreamError is stream error sent by the server.
+type StreamError struct {
+	Type string
+	Text string
+}
+
+func (e *StreamError) Error() string {
+	if len(e.Text) == 0 {
+		return fmt.Sprintf("MCS stream error: %s", e.Type)
+	}
+	return fmt.Sprintf("MCS stream error: %s (%s)", e.Type, e.Text)
+}
+
+// RequiresRegistration reports whether credentials are rejected and registration is needed again.
+// Other stream errors need reconnect only.
+func (e *StreamError) RequiresRegistration() bool {
+	switch e.Type {
+	case "not-authorized", "invalid-from", "host-unknown":
+		return true
+	default:
+		return false
+	}
+}
+
+// Unwrap returns ErrGcmAuthorization when registration is needed again.
+func (e *StreamError) Unwrap() error {
+	if e.RequiresRegistration() {
+		return ErrGcmAuthorization
+	}
+	return nil
+}
+
+// ErrLoginRateLimited is error that the server rejected login for too many requests.
+var ErrLoginRateLimited = errors.New("MCS login rate limited")
+
+// LoginErrorKind is classification of LoginError.
+type LoginErrorKind int
+
+// LoginErrorKind enumeration.
+const (
+	LoginErrorUnknown LoginErrorKind = iota
+	LoginErrorAuthentication
+	LoginErrorRateLimit
+)
+
+func (k LoginErrorKind) String() string {
+	switch k {
+	case LoginErrorAuthentication:
+		return "authentication"
+	case LoginErrorRateLimit:
+		return "rate limit"
+	default:
+		return "unknown"
+	}
+}
+
+// LoginError is error set in login response by the server.
+type LoginError struct {
+	Code    int32
+	Message string
+	Type    string
+	Kind    LoginErrorKind
+}
+
+func newLoginError(code int32, message string, errorType string) *LoginError {
+	kind := LoginErrorUnknown
+	switch {
+	case code == 401 || code == 403 || errorType == "auth" || errorType == "not-authorized":
+		kind = LoginErrorAuthentication
+	case code == 429 || code == 503 || errorType == "wait" || errorType == "resource-constraint":
+		kind = LoginErrorRateLimit
+	}
+	return &LoginError{
+		Code:    code,
+		Message: message,
+		Type:    errorType,
+		Kind:    kind,
+	}
+}
+
+func (e *LoginError) Error() string {
+	return fmt.Sprintf("MCS login error: %s (code=%d, type=%s, message=%s)", e.Kind, e.Code, e.Type, e.Message)
+}
+
+// Unwrap returns ErrGcmAuthorization for authentication error, and ErrLoginRateLimited for rate limit error.
+func (e *LoginError) Unwrap() error {
+	switch e.Kind {
+	case LoginErrorAuthentication:
+		return ErrGcmAuthorization
+	case LoginErrorRateLimit:
+		return ErrLoginRateLimited
+	default:
+		return nil
+	}
+}
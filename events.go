@@ -8,6 +8,8 @@
 package pushreceiver
 
 import (
+	"strings"
+	"sync"
 	"time"
 
 	pb "github.com/crow-misia/go-push-receiver/pb/mcs"
@@ -17,8 +19,45 @@ import (
 type Event any
 
 // ConnectedEvent is connection event.
+// Address is host:port of MCS server connected to.
 type ConnectedEvent struct {
 	ServerTimestamp int64
+	Address         string
+	JID             string
+	StreamID        int32
+	Settings        map[string]string
+	HeartbeatConfig *HeartbeatConfig
+}
+
+// HeartbeatConfig is heartbeat configuration provided by the server.
+type HeartbeatConfig struct {
+	UploadStat bool
+	IP         string
+	Interval   time.Duration
+}
+
+func newConnectedEvent(data *pb.LoginResponse) *ConnectedEvent {
+	settings := make(map[string]string, len(data.GetSetting()))
+	for _, setting := range data.GetSetting() {
+		settings[setting.GetName()] = setting.GetValue()
+	}
+
+	var heartbeatConfig *HeartbeatConfig
+	if config := data.GetHeartbeatConfig(); config != nil {
+		heartbeatConfig = &HeartbeatConfig{
+			UploadStat: config.GetUploadStat(),
+			IP:         config.GetIp(),
+			Interval:   time.Duration(config.GetIntervalMs()) * time.Millisecond,
+		}
+	}
+
+	return &ConnectedEvent{
+		ServerTimestamp: data.GetServerTimestamp(),
+		JID:             data.GetJid(),
+		StreamID:        data.GetStreamId(),
+		Settings:        settings,
+		HeartbeatConfig: heartbeatConfig,
+	}
 }
 
 // RetryEvent is disconnect event.
@@ -32,40 +71,154 @@ type DisconnectedEvent struct {
 	Reason string
 }
 
+// ServerCloseEvent is connection closed by server event.
+type ServerCloseEvent struct{}
+
+// StreamErrorEvent is stream error received from server event.
+type StreamErrorEvent struct {
+	Type                 string
+	Text                 string
+	RequiresRegistration bool
+	ErrorObj             *StreamError
+}
+
+// RawStanzaEvent is received stanza event which the client does not handle.
+type RawStanzaEvent struct {
+	Tag  uint8
+	Name string
+	Data []byte
+}
+
 // HeartbeatEvent is send/received heartbeat event.
+// Send is true when the client sent it, and Ack is true for HeartbeatAck, false for HeartbeatPing.
+// RoundTrip is measured on HeartbeatAck received for HeartbeatPing sent by the client.
 type HeartbeatEvent struct {
 	Send                 bool
 	Ack                  bool
 	Status               int64
 	LastStreamIDReceived int32
+	RoundTrip            time.Duration
 }
 
 // UpdateCredentialsEvent is credentials update event.
+// OldToken is set when the registration token is rotated.
 type UpdateCredentialsEvent struct {
 	Credentials *FCMCredentials
+	OldToken    string
 }
 
 // MessageEvent is received message event.
+// CollapseKey is token field of the stanza, and AppData keeps all app data in received order.
+// Data is decrypted payload when Encrypted, and raw data of the stanza as is otherwise.
 type MessageEvent struct {
-	PersistentID string `json:"persistentId"`
-	From         string `json:"from"`
-	To           string `json:"to"`
-	TTL          int32  `json:"ttl"`
-	Sent         int64  `json:"sent"`
-	Data         []byte `json:"data"`
+	AppID             string    `json:"appId"`
+	PersistentID      string    `json:"persistentId"`
+	ID                string    `json:"id,omitempty"`
+	From              string    `json:"from"`
+	To                string    `json:"to"`
+	Category          string    `json:"category"`
+	CollapseKey       string    `json:"collapseKey,omitempty"`
+	RegID             string    `json:"regId,omitempty"`
+	FromTrustedServer bool      `json:"fromTrustedServer,omitempty"`
+	TTL               int32     `json:"ttl"`
+	Sent              int64     `json:"sent"`
+	Queued            int32     `json:"queued,omitempty"`
+	ImmediateAck      bool      `json:"immediateAck,omitempty"`
+	StreamID          int32     `json:"streamId,omitempty"`
+	AppData           []AppData `json:"appData,omitempty"`
+	Encrypted         bool      `json:"encrypted"`
+	Data              []byte    `json:"data"`
+
+	ack         *messageAck
+	payloadOnce sync.Once
+	payload     *WebPushPayload
+	payloadErr  error
+}
+
+// Ack acknowledges the message to FCM.
+// It is effective only when WithManualAck is enabled, and the message is acknowledged on receive otherwise.
+func (e *MessageEvent) Ack() {
+	if e.ack != nil {
+		e.ack.done(true)
+	}
+}
+
+// Nack leaves the message unacknowledged, and FCM redelivers it after reconnect.
+// It is effective only when WithManualAck is enabled.
+func (e *MessageEvent) Nack() {
+	if e.ack != nil {
+		e.ack.done(false)
+	}
+}
+
+// messageAck is acknowledgement handle of a message, which can be completed once.
+type messageAck struct {
+	once  sync.Once
+	onAck func()
+}
+
+func newMessageAck(onAck func()) *messageAck {
+	return &messageAck{onAck: onAck}
+}
+
+func (a *messageAck) done(ack bool) {
+	a.once.Do(func() {
+		if ack {
+			a.onAck()
+		}
+	})
+}
+
+// AppData is key and value of app data in the message, such as content-encoding, crypto-key and custom headers.
+type AppData struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Header returns value of app data of the key, or empty string.
+// The key is case-insensitive.
+func (e *MessageEvent) Header(key string) string {
+	for _, appData := range e.AppData {
+		if strings.EqualFold(appData.Key, key) {
+			return appData.Value
+		}
+	}
+	return ""
 }
 
 func newMessageEvent(data *pb.DataMessageStanza, bytes []byte) *MessageEvent {
+	appData := make([]AppData, 0, len(data.GetAppData()))
+	for _, d := range data.GetAppData() {
+		appData = append(appData, AppData{Key: d.GetKey(), Value: d.GetValue()})
+	}
+
 	return &MessageEvent{
-		PersistentID: data.GetPersistentId(),
-		From:         data.GetFrom(),
-		To:           data.GetTo(),
-		TTL:          data.GetTtl(),
-		Sent:         data.GetSent(),
-		Data:         bytes,
+		PersistentID:      data.GetPersistentId(),
+		ID:                data.GetId(),
+		From:              data.GetFrom(),
+		To:                data.GetTo(),
+		Category:          data.GetCategory(),
+		CollapseKey:       data.GetToken(),
+		RegID:             data.GetRegId(),
+		FromTrustedServer: data.GetFromTrustedServer(),
+		TTL:               data.GetTtl(),
+		Sent:              data.GetSent(),
+		Queued:            data.GetQueued(),
+		ImmediateAck:      data.GetImmediateAck(),
+		StreamID:          data.GetStreamId(),
+		AppData:           appData,
+		Data:              bytes,
 	}
 }
 
+// DecryptionFailedEvent is message which could not be decrypted event.
+// Message has metadata of the stanza, and Data of it is the encrypted raw data.
+// The message is acknowledged, so that FCM does not redeliver it.
+type DecryptionFailedEvent struct {
+	Message  *MessageEvent
+	ErrorObj error
+}
+
 // HeartbeatError is send heartbeat error.
 type HeartbeatError struct {
 	ErrorObj error
@@ -75,3 +228,15 @@ type HeartbeatError struct {
 type UnauthorizedError struct {
 	ErrorObj error
 }
+
+// OptionError is invalid client options error. Subscribe stops after it.
+type OptionError struct {
+	ErrorObj error
+}
+
+// AppError is registration error of the additional app.
+// Messages to the app are not received until it is registered on next connect.
+type AppError struct {
+	AppID    string
+	ErrorObj error
+}
@@ -1,11 +1,9 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"flag"
-	"fmt"
 	"log/slog"
 	"os"
 	"reflect"
@@ -46,28 +44,23 @@ func realMain(ctx context.Context, configFilename, credsFilename, persistentIDFi
 		os.Exit(-1)
 	}
 
-	creds, err := loadCredentials(credsFilename)
+	// open received persistent id store
+	persistentIDStore, err := pr.NewFilePersistentIDStore(persistentIDFilename, 24*time.Hour)
 	if err != nil {
-		log.Error("failed load credentials", "message", err)
-		os.Exit(-1)
-	}
-
-	// load received persistent id list
-	persistentIDList, err := loadPersistentIDList(persistentIDFilename)
-	if err != nil {
-		log.Error("failed load persistentID list", "message", err)
+		log.Error("failed open persistentID store", "message", err)
 		os.Exit(-1)
 	}
+	defer persistentIDStore.Close()
 
 	fcmClient := pr.New(config,
-		pr.WithCreds(creds),
+		pr.WithCredentialStore(pr.NewFileCredentialStore(credsFilename)),
 		pr.WithHeartbeat(
 			pr.WithServerInterval(1*time.Minute),
 			pr.WithClientInterval(2*time.Minute),
 			pr.WithAdaptive(true),
 		),
 		pr.WithLogger(log),
-		pr.WithReceivedPersistentID(persistentIDList),
+		pr.WithPersistentIDStore(persistentIDStore),
 	)
 
 	go fcmClient.Subscribe(ctx)
@@ -75,28 +68,17 @@ func realMain(ctx context.Context, configFilename, credsFilename, persistentIDFi
 	for event := range fcmClient.Events {
 		switch ev := event.(type) {
 		case *pr.UpdateCredentialsEvent:
-			log.Info("Registration Token:", "token", ev.Credentials.Token)
-			if err := saveCredentials(credsFilename, ev.Credentials); err != nil {
-				log.Error("failed save credentials", "message", err)
-				os.Exit(-1)
-			}
+			log.Info("Registration Token:", "token", ev.Credentials.Token, "oldToken", ev.OldToken)
 		case *pr.ConnectedEvent:
-			if err := clearPersistentID(persistentIDFilename); err != nil {
-				log.Error("failed clear credentials", "message", err)
-				os.Exit(-1)
-			}
+			log.Info("Connected", "jid", ev.JID, "streamID", ev.StreamID)
 		case *pr.UnauthorizedError:
 			log.Warn("UnauthorizedError", "message", err)
 		case *pr.HeartbeatError:
-			log.Warn("HeartbeatError", "message", err)
+			log.Warn("HeartbeatError", "message", ev.ErrorObj)
 		case *pr.MessageEvent:
 			log.Info("Received message:", "data", string(ev.Data), "persistentID", ev.PersistentID)
-
-			// save persistentID
-			if err := savePersistentID(persistentIDFilename, ev.PersistentID); err != nil {
-				log.Error("failed save persistentID", "message", err)
-				os.Exit(-1)
-			}
+		case *pr.DecryptionFailedEvent:
+			log.Warn("DecryptionFailed", "error", ev.ErrorObj, "persistentID", ev.Message.PersistentID)
 		case *pr.RetryEvent:
 			log.Warn("retry:", "error", ev.ErrorObj, "retryAfter", ev.RetryAfter)
 		default:
@@ -125,68 +107,3 @@ func loadConfig(filename string) (*pr.Config, error) {
 	err = json.NewDecoder(f).Decode(config)
 	return config, err
 }
-
-func loadCredentials(filename string) (*pr.FCMCredentials, error) {
-	if !isExist(filename) {
-		return nil, nil
-	}
-
-	f, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	creds := &pr.FCMCredentials{}
-	err = json.NewDecoder(f).Decode(creds)
-	return creds, err
-}
-
-func saveCredentials(filename string, credentials *pr.FCMCredentials) error {
-	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-	if f != nil {
-		defer f.Close()
-	}
-	if err != nil {
-		return err
-	}
-	encoder := json.NewEncoder(f)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(credentials)
-}
-
-func loadPersistentIDList(filename string) ([]string, error) {
-	persistentIDList := make([]string, 0, 100)
-
-	if !isExist(filename) {
-		return persistentIDList, nil
-	}
-
-	f, err := os.Open(filename)
-	if err != nil {
-		return persistentIDList, err
-	}
-	defer f.Close()
-	scanner := bufio.NewScanner(f)
-	scanner.Split(bufio.ScanLines)
-	for scanner.Scan() {
-		persistentIDList = append(persistentIDList, scanner.Text())
-	}
-	return persistentIDList, nil
-}
-
-func savePersistentID(filename, persistentID string) error {
-	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	_, err = f.WriteString(fmt.Sprintln(persistentID))
-	return err
-}
-
-func clearPersistentID(filename string) error {
-	if isExist(filename) {
-		return os.Remove(filename)
-	}
-	return nil
-}
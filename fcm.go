@@ -11,11 +11,13 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
-	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"google.golang.org/protobuf/proto"
@@ -25,7 +27,17 @@ import (
 )
 
 type authToken struct {
-	Token string `json:"token"`
+	Token     string `json:"token"`
+	ExpiresIn string `json:"expiresIn"`
+}
+
+// expiresAt returns expiration time of the auth token.
+func (t *authToken) expiresAt(now time.Time) time.Time {
+	expiresIn, err := time.ParseDuration(t.ExpiresIn)
+	if err != nil {
+		return time.Time{}
+	}
+	return now.Add(expiresIn)
 }
 
 type fcmWebpush struct {
@@ -50,6 +62,15 @@ type fcmRegisterResponse struct {
 	PushSet string `json:"pushSet"`
 }
 
+type fcmGenerateAuthTokenInstallation struct {
+	SdkVersion string `json:"sdkVersion"`
+	AppID      string `json:"appId"`
+}
+
+type fcmGenerateAuthTokenRequest struct {
+	Installation fcmGenerateAuthTokenInstallation `json:"installation"`
+}
+
 type fcmInstallResponse struct {
 	Name         string    `json:"name"`
 	Fid          string    `json:"fid"`
@@ -59,55 +80,128 @@ type fcmInstallResponse struct {
 
 // FCMCredentials is Credentials for FCM
 type FCMCredentials struct {
-	AppID         string `json:"appId"`
-	AndroidID     int64  `json:"androidId"`
-	Endpoint      string `json:"endpoint"`
-	SecurityToken uint64 `json:"securityToken"`
-	Token         string `json:"token"`
-	PrivateKey    []byte `json:"privateKey"`
-	PublicKey     []byte `json:"publicKey"`
-	AuthSecret    []byte `json:"authSecret"`
+	AppID              string    `json:"appId"`
+	AndroidID          int64     `json:"androidId"`
+	Endpoint           string    `json:"endpoint"`
+	SecurityToken      uint64    `json:"securityToken"`
+	Token              string    `json:"token"`
+	PrivateKey         []byte    `json:"privateKey"`
+	PublicKey          []byte    `json:"publicKey"`
+	AuthSecret         []byte    `json:"authSecret"`
+	FID                string    `json:"fid"`
+	RefreshToken       string    `json:"refreshToken"`
+	AuthToken          string    `json:"authToken"`
+	AuthTokenExpiresAt time.Time `json:"authTokenExpiresAt"`
+	TokenUpdatedAt     time.Time `json:"tokenUpdatedAt"`
+
+	// Apps is credentials of additional apps, which share AndroidID and SecurityToken.
+	Apps []*FCMCredentials `json:"apps,omitempty"`
 }
 
 // Subscribe to FCM.
 func (c *Client) Subscribe(ctx context.Context) {
+	_ = c.subscribe(ctx)
+}
+
+// subscribe to FCM, and returns the error which stopped retrying, or nil when ctx is done.
+func (c *Client) subscribe(ctx context.Context) error {
 	defer close(c.Events)
 
+	policy := c.overflowPolicy
+	if c.optionErr != nil {
+		// OptionError is emitted without the spill file, which may be the invalid option.
+		policy = OverflowDropOldest
+	}
+	queue, err := newEventQueue(c.eventQueueSize, policy, c.spillFilename, c.logger, &c.eventCounters)
+	if err != nil {
+		c.logger.Error("failed to create event queue", slog.Any("error", err))
+		return err
+	}
+	c.queue = queue
+	if c.handler != nil {
+		d := newDispatcher(ctx, c.handler, c.handlerWorkers, queue, c.logger)
+		defer func() {
+			queue.close()
+			d.wait()
+			queue.release()
+		}()
+	} else {
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			c.deliverEvents(ctx, queue)
+		})
+		defer func() {
+			queue.close()
+			wg.Wait()
+			queue.release()
+		}()
+	}
+
+	if c.optionErr != nil {
+		c.emit(ctx, &OptionError{c.optionErr})
+		return c.optionErr
+	}
+
+	if c.tokenRefreshPeriod > 0 {
+		refreshCtx, cancelRefresh := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			c.refreshTokenLoop(refreshCtx)
+		})
+		defer func() {
+			cancelRefresh()
+			wg.Wait()
+		}()
+	}
+
 	for ctx.Err() == nil {
-		var err error
-		if c.creds == nil {
-			err = c.register(ctx)
-		} else {
-			_, err = c.checkIn(ctx, &checkInOption{c.creds.AndroidID, c.creds.SecurityToken})
+		err := c.loadCredentials(ctx)
+		if err == nil {
+			if creds := c.credentials(); creds == nil {
+				err = c.register(ctx)
+			} else {
+				_, err = c.checkIn(ctx, &checkInOption{creds.AndroidID, creds.SecurityToken})
+			}
+		}
+		if err == nil {
+			err = c.registerApps(ctx)
 		}
 		if err == nil {
-			// reset retry count when connection success
-			c.backoff.reset()
-
 			err = c.tryToConnect(ctx)
 		}
 		if err != nil {
 			if errors.Is(err, ErrGcmAuthorization) {
-				c.Events <- &UnauthorizedError{err}
-				c.creds = nil
+				c.emit(ctx, &UnauthorizedError{err})
+				c.setCredentials(nil)
 			}
 			if c.retryDisabled {
-				return
+				return err
 			}
 			// retry
 			sleepDuration := c.backoff.duration()
-			c.Events <- &RetryEvent{err, sleepDuration}
+			if errors.Is(err, ErrLoginRateLimited) {
+				// the server asks to slow down, wait for the maximum backoff.
+				sleepDuration = c.backoff.maxDuration()
+			}
+			c.emit(ctx, &RetryEvent{err, sleepDuration})
 			tick := time.After(sleepDuration)
 			select {
 			case <-tick:
 			case <-ctx.Done():
-				return
+				return nil
 			}
 		}
 	}
+	return nil
 }
 
 func (c *Client) register(ctx context.Context) error {
+	release, err := c.acquireConnect(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	register, err := c.registerGCM(ctx)
 	if err != nil {
 		return err
@@ -120,56 +214,137 @@ func (c *Client) register(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	c.creds = creds
-	c.Events <- &UpdateCredentialsEvent{creds}
+	c.setCredentials(creds)
+	c.saveCredentials(ctx, creds)
+	c.emit(ctx, &UpdateCredentialsEvent{Credentials: creds})
+	return nil
+}
+
+// loadCredentials loads credentials from the credential store once.
+func (c *Client) loadCredentials(ctx context.Context) error {
+	if c.credentialStore == nil || c.credsLoaded {
+		return nil
+	}
+	if c.credentials() == nil {
+		creds, err := c.credentialStore.Load(ctx)
+		if err != nil {
+			return errors.Wrap(err, "load credentials")
+		}
+		c.setCredentials(creds)
+	}
+	c.credsLoaded = true
 	return nil
 }
 
+// saveCredentials saves credentials to the credential store.
+func (c *Client) saveCredentials(ctx context.Context, creds *FCMCredentials) {
+	if c.credentialStore == nil {
+		return
+	}
+	if err := c.credentialStore.Save(ctx, creds); err != nil {
+		c.logger.Error("failed to save credentials", slog.Any("error", err))
+	}
+}
+
+// credentials returns current credentials.
+func (c *Client) credentials() *FCMCredentials {
+	c.credsMu.Lock()
+	defer c.credsMu.Unlock()
+	return c.creds
+}
+
+// setCredentials replaces current credentials.
+func (c *Client) setCredentials(creds *FCMCredentials) {
+	c.credsMu.Lock()
+	defer c.credsMu.Unlock()
+	c.creds = creds
+}
+
+// swapCredentials replaces current credentials only when they are not changed from old.
+func (c *Client) swapCredentials(old, creds *FCMCredentials) bool {
+	c.credsMu.Lock()
+	defer c.credsMu.Unlock()
+	if c.creds != old {
+		return false
+	}
+	c.creds = creds
+	return true
+}
+
 func (c *Client) tryToConnect(ctx context.Context) (err error) {
 	childCtx, cancelChild := context.WithCancel(ctx)
 	defer cancelChild()
 
-	conn, err := tls.DialWithDialer(c.dialer, "tcp", mtalkServer, c.tlsConfig)
+	release, err := c.acquireConnect(ctx)
+	if err != nil {
+		return err
+	}
+	conn, addr, err := c.dialMTalk(ctx)
+	release()
 	if err != nil {
 		return errors.Wrap(err, "dial failed to FCM")
 	}
 	defer func() {
-		if cerr := conn.Close(); cerr != nil && err == nil {
+		if cerr := conn.Close(); cerr != nil && !errors.Is(cerr, net.ErrClosed) && err == nil {
 			err = cerr
 		}
 	}()
 
-	mcs := c.newMCS(conn)
+	mcs := c.newMCS(childCtx, conn, addr)
 	defer mcs.disconnect("disconnect")
 
-	err = mcs.SendLoginPacket(ctx, c.receivedPersistentID)
+	// close the socket on cancel to unblock reading, after sending queued acks.
+	closed := make(chan struct{})
+	stopClose := context.AfterFunc(childCtx, func() {
+		defer close(closed)
+		mcs.flushAckOnClose(ctx)
+		_ = conn.Close()
+	})
+	defer func() {
+		if !stopClose() {
+			// closing is started by cancel, wait for it before disconnecting.
+			<-closed
+		}
+	}()
+
+	err = mcs.SendLoginPacket(ctx, c.pendingPersistentIDs(ctx))
 	if err != nil {
 		return errors.Wrap(err, "send login packet failed")
 	}
 
 	// start heartbeat
-	go c.heartbeat.start(
-		childCtx,
-		c.logger,
-		mcs.heartbeatAck,
-		func() error {
-			return mcs.SendHeartbeatPingPacket(ctx)
-		},
-		func() {
-			mcs.disconnect("heartbeat")
-			cancelChild()
-		})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		c.heartbeat.start(
+			childCtx,
+			c.logger,
+			mcs.heartbeatAck,
+			func() error {
+				return mcs.SendHeartbeatPingPacket(ctx)
+			},
+			func() {
+				mcs.disconnect("heartbeat")
+				cancelChild()
+			})
+	})
+	defer func() {
+		cancelChild()
+		wg.Wait()
+	}()
 
+	readResult := c.asyncPerformRead(ctx, mcs)
 	select {
-	case err = <-c.asyncPerformRead(ctx, mcs):
+	case err = <-readResult:
 		return err
 	case <-childCtx.Done():
+		// the socket is closed by cancel, wait for reading to stop.
+		<-readResult
 		return childCtx.Err()
 	}
 }
 
 func (c *Client) asyncPerformRead(ctx context.Context, mcs *mcs) <-chan error {
-	ch := make(chan error)
+	ch := make(chan error, 1)
 	go func() {
 		defer close(ch)
 		ch <- c.performRead(ctx, mcs)
@@ -177,6 +352,26 @@ func (c *Client) asyncPerformRead(ctx context.Context, mcs *mcs) <-chan error {
 	return ch
 }
 
+// emit queues the event for Handler or Events.
+func (c *Client) emit(ctx context.Context, event Event) {
+	c.queue.push(ctx, event)
+}
+
+// deliverEvents sends queued events to Events, or drops them when ctx is done.
+func (c *Client) deliverEvents(ctx context.Context, queue *eventQueue) {
+	for {
+		event, ok := queue.pop()
+		if !ok {
+			return
+		}
+		select {
+		case c.Events <- event:
+		case <-ctx.Done():
+			queue.drop(event, "context done")
+		}
+	}
+}
+
 func (c *Client) performRead(ctx context.Context, mcs *mcs) error {
 	// receive version
 	err := mcs.ReceiveVersion()
@@ -191,33 +386,128 @@ func (c *Client) performRead(ctx context.Context, mcs *mcs) error {
 			return errors.Wrap(err, "receive tag failed")
 		}
 		if data == nil {
-			return ErrFcmNotEnoughData
+			// unknown stanza is skipped.
+			continue
 		}
 
-		err = c.onDataMessage(data)
+		err = c.onDataMessage(ctx, mcs, data)
 		if err != nil {
 			return errors.Wrap(err, "process data message failed")
 		}
 	}
 }
 
-func (c *Client) onDataMessage(tagData proto.Message) error {
+func (c *Client) onDataMessage(ctx context.Context, mcs *mcs, tagData proto.Message) error {
 	switch data := tagData.(type) {
 	case *pb.LoginResponse:
-		c.receivedPersistentID = nil
-		c.Events <- &ConnectedEvent{data.GetServerTimestamp()}
+		if loginErr := data.GetError(); loginErr != nil {
+			return newLoginError(loginErr.GetCode(), loginErr.GetMessage(), loginErr.GetType())
+		}
+
+		// reset retry count when connection success
+		c.backoff.reset()
+
+		c.confirmPersistentIDs(ctx, mcs.loginPersistentIds)
+
+		c.rememberMTalk(mcs.addr)
+
+		event := newConnectedEvent(data)
+		event.Address = mcs.addr
+		c.heartbeat.configure(event.HeartbeatConfig)
+		c.emit(ctx, event)
 	case *pb.DataMessageStanza:
-		// To avoid error loops, last streamId is notified even when an error occurs.
-		c.receivedPersistentID = append(c.receivedPersistentID, data.GetPersistentId())
-		event, err := decryptData(data, c.creds)
+		persistentID := data.GetPersistentId()
+		if c.isDuplicated(ctx, persistentID) {
+			// FCM redelivered the message processed already, acknowledge it again.
+			c.logger.Debug("skip duplicated message", "persistentID", persistentID)
+			c.ackPersistentID(ctx, mcs, persistentID)
+			return nil
+		}
+		creds := mcs.creds.route(data)
+		event, err := decryptData(data, creds)
+		// iterators acknowledge messages after yielding them.
+		deferAck := c.manualAck || c.iterAck
+		if err != nil || !deferAck {
+			// To avoid redelivery loops, the message is acknowledged even when it can not be decrypted.
+			c.ackPersistentID(ctx, mcs, persistentID)
+		}
 		if err != nil {
-			return err
+			c.logger.Warn("failed to decrypt message", "persistentID", persistentID, slog.Any("error", err))
+			failed := newMessageEvent(data, data.GetRawData())
+			failed.AppID = creds.AppID
+			c.emit(ctx, &DecryptionFailedEvent{Message: failed, ErrorObj: err})
+			return nil
+		}
+		event.AppID = creds.AppID
+		if deferAck {
+			event.ack = newMessageAck(func() {
+				c.ackPersistentID(ctx, mcs, persistentID)
+			})
 		}
-		c.Events <- event
+		c.emit(ctx, event)
+	case *pb.Close:
+		c.emit(ctx, &ServerCloseEvent{})
+		return ErrServerClosed
+	case *pb.StreamErrorStanza:
+		streamErr := &StreamError{Type: data.GetType(), Text: data.GetText()}
+		c.emit(ctx, &StreamErrorEvent{
+			Type:                 streamErr.Type,
+			Text:                 streamErr.Text,
+			RequiresRegistration: streamErr.RequiresRegistration(),
+			ErrorObj:             streamErr,
+		})
+		return streamErr
 	}
 	return nil
 }
 
+// ackPersistentID reports the persistent id to FCM as received.
+func (c *Client) ackPersistentID(ctx context.Context, mcs *mcs, persistentID string) {
+	if len(persistentID) == 0 {
+		return
+	}
+
+	if err := c.persistentIDStore.Acked(ctx, persistentID); err != nil {
+		c.logger.Error("failed to store persistent id", "persistentID", persistentID, slog.Any("error", err))
+	}
+
+	if err := mcs.queueAck(ctx, persistentID); err != nil {
+		c.logger.Debug("failed to acknowledge message", "persistentID", persistentID, slog.Any("error", err))
+	}
+}
+
+// isDuplicated reports whether the message of the persistent id has been acknowledged already.
+func (c *Client) isDuplicated(ctx context.Context, persistentID string) bool {
+	if len(persistentID) == 0 {
+		return false
+	}
+	received, err := c.persistentIDStore.Received(ctx, persistentID)
+	if err != nil {
+		c.logger.Error("failed to lookup persistent id", "persistentID", persistentID, slog.Any("error", err))
+		return false
+	}
+	return received
+}
+
+// pendingPersistentIDs returns persistent ids received but not confirmed yet.
+func (c *Client) pendingPersistentIDs(ctx context.Context) []string {
+	ids, err := c.persistentIDStore.Pending(ctx)
+	if err != nil {
+		c.logger.Error("failed to load persistent ids", slog.Any("error", err))
+	}
+	return ids
+}
+
+// confirmPersistentIDs records persistent ids which the server confirmed acknowledgement.
+func (c *Client) confirmPersistentIDs(ctx context.Context, persistentIDs []string) {
+	if len(persistentIDs) == 0 {
+		return
+	}
+	if err := c.persistentIDStore.Confirmed(ctx, persistentIDs); err != nil {
+		c.logger.Error("failed to store confirmed persistent ids", slog.Any("error", err))
+	}
+}
+
 func (c *Client) installFCM(ctx context.Context) (*fcmInstallResponse, error) {
 	fid, err := generateFID()
 	if err != nil {
@@ -236,7 +526,7 @@ func (c *Client) installFCM(ctx context.Context) (*fcmInstallResponse, error) {
 		return nil, errors.Wrap(err, "marshal FCM install request")
 	}
 
-	url := fmt.Sprintf("%sprojects/%s/installations", firebaseInstallationURL, c.projectID)
+	url := fmt.Sprintf("%sprojects/%s/installations", c.endpoints.FirebaseInstallation, c.projectID)
 
 	res, err := c.post(ctx, url, bytes.NewReader(bodyBytes), func(header *http.Header) {
 		header.Set("Accept", "application/json")
@@ -287,7 +577,7 @@ func (c *Client) registerFCM(ctx context.Context, registerResponse *gcmRegisterR
 		return nil, errors.Wrap(err, "marshal FCM register request")
 	}
 
-	url := fmt.Sprintf("%sprojects/%s/registrations", firebaseRegistrationURL, c.projectID)
+	url := fmt.Sprintf("%sprojects/%s/registrations", c.endpoints.FirebaseRegistration, c.projectID)
 
 	res, err := c.post(ctx, url, bytes.NewReader(bodyBytes), func(header *http.Header) {
 		header.Set("Content-Type", "application/json")
@@ -314,10 +604,155 @@ func (c *Client) registerFCM(ctx context.Context, registerResponse *gcmRegisterR
 	credentials.SecurityToken = registerResponse.securityToken
 	credentials.Token = fcmRegisterResponse.Token
 	credentials.Endpoint = endpoint
+	credentials.FID = installResponse.Fid
+	credentials.RefreshToken = installResponse.RefreshToken
+	credentials.AuthToken = installResponse.AuthToken.Token
+	credentials.AuthTokenExpiresAt = installResponse.AuthToken.expiresAt(time.Now())
+	credentials.TokenUpdatedAt = time.Now()
 
 	return credentials, nil
 }
 
+// ensureAuthToken generates new Firebase installation auth token when it is expired, and reports whether credentials are updated.
+func (c *Client) ensureAuthToken(ctx context.Context, creds *FCMCredentials) (bool, error) {
+	if len(creds.AuthToken) > 0 && time.Until(creds.AuthTokenExpiresAt) > authTokenExpirationBuffer*time.Minute {
+		return false, nil
+	}
+	if len(creds.FID) == 0 || len(creds.RefreshToken) == 0 {
+		return false, ErrMissingInstallation
+	}
+
+	token, err := c.generateAuthToken(ctx, creds)
+	if err != nil {
+		return false, err
+	}
+	creds.AuthToken = token.Token
+	creds.AuthTokenExpiresAt = token.expiresAt(time.Now())
+	return true, nil
+}
+
+func (c *Client) generateAuthToken(ctx context.Context, creds *FCMCredentials) (*authToken, error) {
+	// refs. https://github.com/firebase/firebase-js-sdk/blob/main/packages/installations/src/functions/generate-auth-token-request.ts
+	body := fcmGenerateAuthTokenRequest{
+		Installation: fcmGenerateAuthTokenInstallation{
+			SdkVersion: sdkVersion,
+			AppID:      c.appID,
+		},
+	}
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal FCM generate auth token request")
+	}
+
+	url := fmt.Sprintf("%sprojects/%s/installations/%s/authTokens:generate", c.endpoints.FirebaseInstallation, c.projectID, creds.FID)
+
+	res, err := c.post(ctx, url, bytes.NewReader(bodyBytes), func(header *http.Header) {
+		header.Set("Accept", "application/json")
+		header.Set("Content-Type", "application/json")
+		header.Set("x-goog-api-key", c.apiKey)
+		header.Set("Authorization", fmt.Sprintf("%s %s", authVersion, creds.RefreshToken))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "request FCM generate auth token")
+	}
+	defer closeResponse(c.logger, res)
+
+	// refresh token is revoked, the installation must be created again.
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusNotFound {
+		return nil, ErrGcmAuthorization
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, errors.Errorf("server error: %s", res.Status)
+	}
+
+	var token authToken
+	err = json.NewDecoder(res.Body).Decode(&token)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal FCM generate auth token response")
+	}
+	return &token, nil
+}
+
+func (c *Client) updateFCM(ctx context.Context, creds *FCMCredentials) (string, error) {
+	// refs. https://github.com/firebase/firebase-js-sdk/blob/main/packages/messaging/src/internals/requests.ts
+	body := fcmRegisterRequest{
+		Web: fcmWebpush{
+			ApplicationPubKey: c.vapidKey,
+			Endpoint:          creds.Endpoint,
+			P256Dh:            base64.URLEncoding.EncodeToString(creds.PublicKey),
+			Auth:              base64.URLEncoding.EncodeToString(creds.AuthSecret),
+		},
+	}
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal FCM update request")
+	}
+
+	url := fmt.Sprintf("%sprojects/%s/registrations/%s", c.endpoints.FirebaseRegistration, c.projectID, creds.Token)
+
+	res, err := c.patch(ctx, url, bytes.NewReader(bodyBytes), func(header *http.Header) {
+		header.Set("Accept", "application/json")
+		header.Set("Content-Type", "application/json")
+		header.Set("x-goog-api-key", c.apiKey)
+		header.Set("x-goog-firebase-installations-auth", fmt.Sprintf("FIS %s", creds.AuthToken))
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "request FCM update")
+	}
+	defer closeResponse(c.logger, res)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", errors.Errorf("server error: %s", res.Status)
+	}
+	var fcmRegisterResponse fcmRegisterResponse
+	err = json.NewDecoder(res.Body).Decode(&fcmRegisterResponse)
+	if err != nil {
+		return "", errors.Wrap(err, "unmarshal FCM update response")
+	}
+	if len(fcmRegisterResponse.Token) == 0 {
+		return "", errors.New("FCM update response has no token")
+	}
+	return fcmRegisterResponse.Token, nil
+}
+
+func (c *Client) deleteFCM(ctx context.Context, creds *FCMCredentials) error {
+	url := fmt.Sprintf("%sprojects/%s/registrations/%s", c.endpoints.FirebaseRegistration, c.projectID, creds.Token)
+
+	res, err := c.delete(ctx, url, func(header *http.Header) {
+		header.Set("Accept", "application/json")
+		header.Set("x-goog-api-key", c.apiKey)
+		header.Set("x-goog-firebase-installations-auth", fmt.Sprintf("FIS %s", creds.AuthToken))
+	})
+	if err != nil {
+		return errors.Wrap(err, "request FCM delete")
+	}
+	defer closeResponse(c.logger, res)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("server error: %s", res.Status)
+	}
+	return nil
+}
+
+func (c *Client) deleteInstallation(ctx context.Context, creds *FCMCredentials) error {
+	url := fmt.Sprintf("%sprojects/%s/installations/%s", c.endpoints.FirebaseInstallation, c.projectID, creds.FID)
+
+	res, err := c.delete(ctx, url, func(header *http.Header) {
+		header.Set("Accept", "application/json")
+		header.Set("x-goog-api-key", c.apiKey)
+		header.Set("Authorization", fmt.Sprintf("%s %s", authVersion, creds.RefreshToken))
+	})
+	if err != nil {
+		return errors.Wrap(err, "request FCM installation delete")
+	}
+	defer closeResponse(c.logger, res)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("server error: %s", res.Status)
+	}
+	return nil
+}
+
 func generateFID() (string, error) {
 	// refs. https://github.com/firebase/firebase-js-sdk/blob/main/packages/installations/src/helpers/generate-fid.ts
 
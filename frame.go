@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"bufio"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// framePool pools buffers of MCS frames.
+var framePool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, framePoolBufferSize)
+		return &buf
+	},
+}
+
+// getFrameBuffer returns empty buffer from pool.
+func getFrameBuffer() *[]byte {
+	buf, _ := framePool.Get().(*[]byte)
+	return buf
+}
+
+// putFrameBuffer returns buffer to pool, too large buffer is released instead.
+func putFrameBuffer(buf *[]byte) {
+	if buf == nil || cap(*buf) > framePoolMaxBufferSize {
+		return
+	}
+	*buf = (*buf)[:0]
+	framePool.Put(buf)
+}
+
+// frameReader reads MCS frames from buffered connection.
+type frameReader struct {
+	r       *bufio.Reader
+	maxSize uint64
+}
+
+func newFrameReader(r io.Reader, maxSize int) *frameReader {
+	return &frameReader{
+		r:       bufio.NewReaderSize(r, frameReaderBufferSize),
+		maxSize: uint64(max(maxSize, 0)),
+	}
+}
+
+// readVersion reads MCS version packet.
+func (fr *frameReader) readVersion() (byte, error) {
+	return fr.r.ReadByte()
+}
+
+// readFrame reads tag, size and data packets.
+// The returned buffer should be released by putFrameBuffer after use.
+func (fr *frameReader) readFrame() (tagType, *[]byte, error) {
+	// receive tag
+	tag, err := fr.r.ReadByte()
+	if err != nil {
+		return tagUnknown, nil, errors.Wrap(err, "receive tag packet")
+	}
+
+	// receive size
+	size, err := fr.readSize()
+	if err != nil {
+		return tagUnknown, nil, errors.Wrap(err, "receive size packet")
+	}
+	if size > fr.maxSize {
+		return tagUnknown, nil, errors.Wrapf(ErrFrameTooLarge, "tag %s, size %d", tagType(tag), size)
+	}
+
+	// receive data
+	buf := getFrameBuffer()
+	if uint64(cap(*buf)) < size {
+		*buf = make([]byte, size)
+	} else {
+		*buf = (*buf)[:size]
+	}
+	if _, err = io.ReadFull(fr.r, *buf); err != nil {
+		putFrameBuffer(buf)
+		return tagUnknown, nil, errors.Wrap(err, "receive data packet")
+	}
+	return tagType(tag), buf, nil
+}
+
+// readSize reads varint size packet.
+func (fr *frameReader) readSize() (uint64, error) {
+	var size uint64
+	for i := range sizePacketLenMax {
+		b, err := fr.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		size |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return size, nil
+		}
+	}
+	return 0, errors.Wrap(ErrFrameTooLarge, "size packet overflow")
+}
+
+// appendFrame appends version (optional), tag, size and data packets of request to buf.
+func appendFrame(buf []byte, tag tagType, request proto.Message, containVersion bool) ([]byte, error) {
+	if containVersion {
+		buf = append(buf, fcmVersion)
+	}
+	buf = append(buf, byte(tag))
+
+	requestSize := proto.Size(request)
+	if requestSize < 0 {
+		return buf, errors.Errorf("invalid request size %d", requestSize)
+	}
+	buf = protowire.AppendVarint(buf, uint64(requestSize))
+
+	buf, err := proto.MarshalOptions{UseCachedSize: true}.MarshalAppend(buf, request)
+	if err != nil {
+		return buf, errors.Wrap(err, "encode protocol buffer data")
+	}
+	return buf, nil
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/crow-misia/go-push-receiver/pb/mcs"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func testDataMessage() *pb.DataMessageStanza {
+	return &pb.DataMessageStanza{
+		From:         proto.String("123456789"),
+		Category:     proto.String("org.chromium.linux"),
+		PersistentId: proto.String("0:1234567890%abcdef"),
+		AppData: []*pb.AppData{
+			{Key: proto.String("content-encoding"), Value: proto.String("aes128gcm")},
+		},
+		RawData: bytes.Repeat([]byte{0x5a}, 1024),
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	request := testDataMessage()
+	frame, err := appendFrame(nil, tagDataMessageStanza, request, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := newFrameReader(bytes.NewReader(frame), defaultMaxFrameSize)
+	version, err := reader.readVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != fcmVersion {
+		t.Fatalf("version = %d, want %d", version, fcmVersion)
+	}
+	tag, buf, err := reader.readFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer putFrameBuffer(buf)
+	if tag != tagDataMessageStanza {
+		t.Fatalf("tag = %s, want %s", tag, tagDataMessageStanza)
+	}
+
+	var got pb.DataMessageStanza
+	if err := proto.Unmarshal(*buf, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&got, request) {
+		t.Fatalf("frame = %v, want %v", &got, request)
+	}
+}
+
+func TestFrameTooLarge(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{
+			name:  "size exceeds max",
+			frame: protowire.AppendVarint([]byte{byte(tagDataMessageStanza)}, 1024+1),
+		},
+		{
+			name:  "size packet overflow",
+			frame: []byte{byte(tagDataMessageStanza), 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newFrameReader(bytes.NewReader(tt.frame), 1024)
+			if _, _, err := reader.readFrame(); !errors.Is(err, ErrFrameTooLarge) {
+				t.Fatalf("err = %v, want %v", err, ErrFrameTooLarge)
+			}
+		})
+	}
+}
+
+func TestNewMCSFrameReader(t *testing.T) {
+	c, err := NewClient(&Config{}, WithMaxFrameSize(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mcs := c.newMCS(context.Background(), nil, "")
+	if mcs.reader == nil {
+		t.Fatal("frame reader is not set")
+	}
+	if mcs.reader.maxSize != 1024 {
+		t.Fatalf("max frame size = %d, want %d", mcs.reader.maxSize, 1024)
+	}
+}
+
+func BenchmarkReadFrame(b *testing.B) {
+	frame, err := appendFrame(nil, tagDataMessageStanza, testDataMessage(), false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	src := bytes.NewReader(nil)
+	reader := newFrameReader(src, defaultMaxFrameSize)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(frame)))
+	for b.Loop() {
+		src.Reset(frame)
+		reader.r.Reset(src)
+		_, buf, err := reader.readFrame()
+		if err != nil {
+			b.Fatal(err)
+		}
+		putFrameBuffer(buf)
+	}
+}
+
+func BenchmarkAppendFrame(b *testing.B) {
+	request := testDataMessage()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		buf := getFrameBuffer()
+		frame, err := appendFrame(*buf, tagDataMessageStanza, request, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		*buf = frame
+		putFrameBuffer(buf)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Handler receives events of the client instead of Events channel.
+// ServerCloseEvent and StreamErrorEvent are notified by OnError, and other events by EventHandler.
+// Methods are called on the worker pool concurrently, so they must be safe for concurrent use.
+type Handler interface {
+	OnMessage(ctx context.Context, event *MessageEvent)
+	OnConnected(ctx context.Context, event *ConnectedEvent)
+	OnDisconnected(ctx context.Context, event *DisconnectedEvent)
+	OnCredentialsUpdated(ctx context.Context, event *UpdateCredentialsEvent)
+	OnRetry(ctx context.Context, event *RetryEvent)
+	OnError(ctx context.Context, err error)
+}
+
+// EventHandler is optionally implemented by Handler to receive other events,
+// such as HeartbeatEvent, RawStanzaEvent and ServerCloseEvent.
+type EventHandler interface {
+	OnEvent(ctx context.Context, event Event)
+}
+
+// HandlerFuncs is Handler with functions, nil function ignores the event.
+type HandlerFuncs struct {
+	Message            func(ctx context.Context, event *MessageEvent)
+	Connected          func(ctx context.Context, event *ConnectedEvent)
+	Disconnected       func(ctx context.Context, event *DisconnectedEvent)
+	CredentialsUpdated func(ctx context.Context, event *UpdateCredentialsEvent)
+	Retry              func(ctx context.Context, event *RetryEvent)
+	Error              func(ctx context.Context, err error)
+	Event              func(ctx context.Context, event Event)
+}
+
+var (
+	_ Handler      = (*HandlerFuncs)(nil)
+	_ EventHandler = (*HandlerFuncs)(nil)
+)
+
+// OnMessage calls Message.
+func (h *HandlerFuncs) OnMessage(ctx context.Context, event *MessageEvent) {
+	if h.Message != nil {
+		h.Message(ctx, event)
+	}
+}
+
+// OnConnected calls Connected.
+func (h *HandlerFuncs) OnConnected(ctx context.Context, event *ConnectedEvent) {
+	if h.Connected != nil {
+		h.Connected(ctx, event)
+	}
+}
+
+// OnDisconnected calls Disconnected.
+func (h *HandlerFuncs) OnDisconnected(ctx context.Context, event *DisconnectedEvent) {
+	if h.Disconnected != nil {
+		h.Disconnected(ctx, event)
+	}
+}
+
+// OnCredentialsUpdated calls CredentialsUpdated.
+func (h *HandlerFuncs) OnCredentialsUpdated(ctx context.Context, event *UpdateCredentialsEvent) {
+	if h.CredentialsUpdated != nil {
+		h.CredentialsUpdated(ctx, event)
+	}
+}
+
+// OnRetry calls Retry.
+func (h *HandlerFuncs) OnRetry(ctx context.Context, event *RetryEvent) {
+	if h.Retry != nil {
+		h.Retry(ctx, event)
+	}
+}
+
+// OnError calls Error.
+func (h *HandlerFuncs) OnError(ctx context.Context, err error) {
+	if h.Error != nil {
+		h.Error(ctx, err)
+	}
+}
+
+// OnEvent calls Event.
+func (h *HandlerFuncs) OnEvent(ctx context.Context, event Event) {
+	if h.Event != nil {
+		h.Event(ctx, event)
+	}
+}
+
+// dispatcher calls handler with events of the queue on the worker pool.
+type dispatcher struct {
+	handler Handler
+	logger  *slog.Logger
+	ctx     context.Context
+	queue   *eventQueue
+	wg      sync.WaitGroup
+}
+
+func newDispatcher(ctx context.Context, handler Handler, workers int, queue *eventQueue, logger *slog.Logger) *dispatcher {
+	d := &dispatcher{
+		handler: handler,
+		logger:  logger,
+		ctx:     ctx,
+		queue:   queue,
+	}
+	for range workers {
+		d.wg.Go(d.work)
+	}
+	return d
+}
+
+// wait waits for workers to handle all events of the closed queue.
+func (d *dispatcher) wait() {
+	d.wg.Wait()
+}
+
+func (d *dispatcher) work() {
+	for {
+		event, ok := d.queue.pop()
+		if !ok {
+			return
+		}
+		d.handle(event)
+	}
+}
+
+// handle calls the handler method of the event, and recovers panic of it.
+func (d *dispatcher) handle(event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Error("handler panic",
+				"event", fmt.Sprintf("%T", event),
+				"panic", r,
+				"stack", string(debug.Stack()))
+		}
+	}()
+
+	switch ev := event.(type) {
+	case *MessageEvent:
+		d.handler.OnMessage(d.ctx, ev)
+	case *ConnectedEvent:
+		d.handler.OnConnected(d.ctx, ev)
+	case *DisconnectedEvent:
+		d.handler.OnDisconnected(d.ctx, ev)
+	case *UpdateCredentialsEvent:
+		d.handler.OnCredentialsUpdated(d.ctx, ev)
+	case *RetryEvent:
+		d.handler.OnRetry(d.ctx, ev)
+	case *HeartbeatError:
+		d.handler.OnError(d.ctx, ev.ErrorObj)
+	case *UnauthorizedError:
+		d.handler.OnError(d.ctx, ev.ErrorObj)
+	case *OptionError:
+		d.handler.OnError(d.ctx, ev.ErrorObj)
+	case *AppError:
+		d.handler.OnError(d.ctx, errors.Wrapf(ev.ErrorObj, "register app %s", ev.AppID))
+	case *DecryptionFailedEvent:
+		d.handler.OnError(d.ctx, ev.ErrorObj)
+	case *StreamErrorEvent:
+		d.handler.OnError(d.ctx, ev.ErrorObj)
+	case *ServerCloseEvent:
+		d.handler.OnError(d.ctx, ErrServerClosed)
+	}
+
+	// events without typed method go to EventHandler, and ServerCloseEvent and StreamErrorEvent go as well for details.
+	switch event.(type) {
+	case *MessageEvent, *ConnectedEvent, *DisconnectedEvent, *UpdateCredentialsEvent, *RetryEvent,
+		*HeartbeatError, *UnauthorizedError, *OptionError, *AppError, *DecryptionFailedEvent:
+	default:
+		if h, ok := d.handler.(EventHandler); ok {
+			h.OnEvent(d.ctx, event)
+		}
+	}
+}
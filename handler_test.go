@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestDispatcherHandle(t *testing.T) {
+	var errs []error
+	var events []Event
+	d := &dispatcher{
+		handler: &HandlerFuncs{
+			Message: func(context.Context, *MessageEvent) {
+				panic("handler panic")
+			},
+			Error: func(_ context.Context, err error) {
+				errs = append(errs, err)
+			},
+			Event: func(_ context.Context, event Event) {
+				events = append(events, event)
+			},
+		},
+		logger: slog.New(noOpHandler{}),
+		ctx:    context.Background(),
+	}
+
+	d.handle(&MessageEvent{})
+	d.handle(&ServerCloseEvent{})
+	streamErr := &StreamError{Type: "not-authorized"}
+	d.handle(&StreamErrorEvent{Type: streamErr.Type, RequiresRegistration: true, ErrorObj: streamErr})
+	d.handle(&HeartbeatEvent{})
+	d.handle(&AppError{AppID: "app", ErrorObj: ErrFcmNotEnoughData})
+	d.handle(&DecryptionFailedEvent{Message: &MessageEvent{}, ErrorObj: ErrNotFoundInAppData})
+
+	if len(errs) != 4 || !errors.Is(errs[0], ErrServerClosed) || !errors.Is(errs[1], ErrGcmAuthorization) ||
+		!errors.Is(errs[2], ErrFcmNotEnoughData) || !errors.Is(errs[3], ErrNotFoundInAppData) {
+		t.Fatalf("errors = %v", errs)
+	}
+	if len(events) != 3 {
+		t.Fatalf("events = %v", events)
+	}
+}
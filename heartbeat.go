@@ -10,7 +10,11 @@ package pushreceiver
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
+
+	pb "github.com/crow-misia/go-push-receiver/pb/mcs"
+	"google.golang.org/protobuf/proto"
 )
 
 // Heartbeat sends signal for connection keep alive.
@@ -19,6 +23,18 @@ type Heartbeat struct {
 	serverInterval time.Duration
 	deadmanTimeout time.Duration
 	adaptive       bool
+	adaptiveStep   time.Duration
+	adaptiveMax    time.Duration
+
+	mu         sync.Mutex
+	ip         string
+	interval   time.Duration
+	ceiling    time.Duration
+	uploadStat bool
+	configured chan struct{}
+
+	configInterval time.Duration
+	stat           *pb.HeartbeatStat
 }
 
 // HeartbeatOption type
@@ -53,8 +69,26 @@ func WithAdaptive(enabled bool) HeartbeatOption {
 	}
 }
 
+// WithAdaptiveStep is heartbeat interval step of adaptive probing setter
+func WithAdaptiveStep(step time.Duration) HeartbeatOption {
+	return func(heartbeat *Heartbeat) {
+		heartbeat.adaptiveStep = step
+	}
+}
+
+// WithAdaptiveMaxInterval is heartbeat maximum interval of adaptive probing setter
+func WithAdaptiveMaxInterval(interval time.Duration) HeartbeatOption {
+	return func(heartbeat *Heartbeat) {
+		heartbeat.adaptiveMax = interval
+	}
+}
+
 func newHeartbeat(options ...HeartbeatOption) *Heartbeat {
-	h := &Heartbeat{}
+	h := &Heartbeat{
+		adaptiveStep: defaultAdaptiveStep * time.Minute,
+		adaptiveMax:  defaultAdaptiveMax * time.Minute,
+		configured:   make(chan struct{}, 1),
+	}
 	for _, option := range options {
 		option(h)
 	}
@@ -62,20 +96,12 @@ func newHeartbeat(options ...HeartbeatOption) *Heartbeat {
 }
 
 func (h *Heartbeat) start(ctx context.Context, logger *slog.Logger, heartbeatAck chan bool, sendHeartbeat func() error, onDisconnect func()) {
-	if h.deadmanTimeout <= 0 {
-		if h.clientInterval < h.serverInterval {
-			h.deadmanTimeout = durationDeadmanTimeout(h.serverInterval)
-		} else {
-			h.deadmanTimeout = durationDeadmanTimeout(h.clientInterval)
-		}
-	}
-
 	var (
 		pingDeadman  *time.Timer
 		pingDeadmanC <-chan time.Time
 	)
-	if h.deadmanTimeout > 0 {
-		pingDeadman = time.NewTimer(h.deadmanTimeout)
+	if deadman := h.deadman(); deadman > 0 {
+		pingDeadman = time.NewTimer(deadman)
 		pingDeadmanC = pingDeadman.C
 	}
 	defer func() {
@@ -86,42 +112,196 @@ func (h *Heartbeat) start(ctx context.Context, logger *slog.Logger, heartbeatAck
 	}()
 
 	var (
-		pingTicker  *time.Ticker
-		pingTickerC <-chan time.Time
+		pingTimer  *time.Timer
+		pingTimerC <-chan time.Time
 	)
-	if h.clientInterval > 0 {
-		pingTicker = time.NewTicker(h.clientInterval)
-		pingTickerC = pingTicker.C
+	if interval := h.currentInterval(); interval > 0 {
+		pingTimer = time.NewTimer(interval)
+		pingTimerC = pingTimer.C
 	}
 	defer func() {
-		if pingTicker != nil {
-			pingTicker.Stop()
+		if pingTimer != nil {
+			pingTimer.Stop()
 		}
 	}()
 
+	awaitingAck := false
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-heartbeatAck:
+		case <-h.configured:
+			// interval is configured by login response, apply it without waiting for the current timer.
+			if interval := h.currentInterval(); interval > 0 && !awaitingAck {
+				if pingTimer == nil {
+					pingTimer = time.NewTimer(interval)
+					pingTimerC = pingTimer.C
+				} else {
+					pingTimer.Reset(interval)
+				}
+			}
+			if deadman := h.deadman(); deadman > 0 {
+				if pingDeadman == nil {
+					pingDeadman = time.NewTimer(deadman)
+					pingDeadmanC = pingDeadman.C
+				} else {
+					pingDeadman.Reset(deadman)
+				}
+			}
+		case _, ok := <-heartbeatAck:
+			if !ok {
+				return
+			}
+			if awaitingAck {
+				awaitingAck = false
+				h.succeeded()
+				if pingTimer != nil {
+					pingTimer.Reset(h.currentInterval())
+				}
+			}
 			if pingDeadman != nil {
-				pingDeadman.Reset(h.deadmanTimeout)
+				pingDeadman.Reset(h.deadman())
 			}
 		case <-pingDeadmanC:
 			// force disconnect
 			logger.Info("force disconnect by heartbeat")
+			h.timedOut()
 			onDisconnect()
 			return
-		case <-pingTickerC:
+		case <-pingTimerC:
 			// send heartbeat to FCM
 			err := sendHeartbeat()
 			if err != nil {
+				// connection is broken, force disconnect
+				logger.Info("force disconnect by heartbeat send failure", slog.Any("error", err))
+				onDisconnect()
 				return
 			}
+			awaitingAck = true
+			pingTimer.Reset(h.currentInterval())
 		}
 	}
 }
 
+// configure applies heartbeat configuration provided by the server in login response.
+func (h *Heartbeat) configure(config *HeartbeatConfig) {
+	if config == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.uploadStat = config.UploadStat
+	if config.IP != h.ip {
+		// network changed, probe again from the interval suggested by the server.
+		h.ip = config.IP
+		h.interval = 0
+		h.ceiling = 0
+	}
+	h.configInterval = config.Interval
+	if h.adaptive && h.interval <= 0 && config.Interval > 0 {
+		// adaptive probing starts from the interval suggested by the server.
+		h.interval = config.Interval
+	}
+
+	// notify running heartbeat of the connection.
+	select {
+	case h.configured <- struct{}{}:
+	default:
+	}
+}
+
+// currentInterval returns interval to send heartbeat.
+func (h *Heartbeat) currentInterval() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.adaptive && h.interval > 0 {
+		return h.interval
+	}
+	// the interval suggested by the server is used only when it is shorter than the client interval.
+	if h.configInterval > 0 && (h.clientInterval <= 0 || h.configInterval < h.clientInterval) {
+		return h.configInterval
+	}
+	return h.clientInterval
+}
+
+// deadman returns timeout to force disconnect when no heartbeat ack is received.
+func (h *Heartbeat) deadman() time.Duration {
+	if h.deadmanTimeout > 0 {
+		return h.deadmanTimeout
+	}
+	return durationDeadmanTimeout(max(h.currentInterval(), h.serverInterval))
+}
+
+// succeeded records heartbeat ack, and probes longer interval on adaptive mode.
+func (h *Heartbeat) succeeded() {
+	if !h.adaptive {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.interval
+	if current <= 0 {
+		current = h.clientInterval
+	}
+	h.stat = &pb.HeartbeatStat{
+		Ip:         proto.String(h.ip),
+		Timeout:    proto.Bool(false),
+		IntervalMs: proto.Int32(int32(current.Milliseconds())),
+	}
+
+	next := min(current+h.adaptiveStep, h.adaptiveMax)
+	if h.ceiling > 0 && next >= h.ceiling {
+		return
+	}
+	h.interval = max(next, current)
+}
+
+// timedOut records heartbeat timeout, and backs off interval on adaptive mode.
+func (h *Heartbeat) timedOut() {
+	if !h.adaptive {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.interval
+	if current <= 0 {
+		current = h.clientInterval
+	}
+	h.stat = &pb.HeartbeatStat{
+		Ip:         proto.String(h.ip),
+		Timeout:    proto.Bool(true),
+		IntervalMs: proto.Int32(int32(current.Milliseconds())),
+	}
+
+	// the NAT timeout is shorter than current interval.
+	h.ceiling = current
+	h.interval = max(current-h.adaptiveStep, minHeartbeatInterval*time.Minute)
+}
+
+// loginStat returns heartbeat statistics to report in login request.
+func (h *Heartbeat) loginStat() *pb.HeartbeatStat {
+	if !h.adaptive {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.uploadStat || h.stat == nil {
+		return nil
+	}
+	stat := h.stat
+	h.stat = nil
+	return stat
+}
+
 func durationDeadmanTimeout(interval time.Duration) time.Duration {
 	return interval * 4
 }
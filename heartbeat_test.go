@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatConfiguredByLoginResponse(t *testing.T) {
+	h := newHeartbeat(WithServerInterval(time.Minute))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sent := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.start(ctx, slog.New(noOpHandler{}), make(chan bool, 1), func() error {
+			select {
+			case sent <- struct{}{}:
+			default:
+			}
+			return nil
+		}, func() {})
+	}()
+
+	h.configure(&HeartbeatConfig{Interval: 10 * time.Millisecond})
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("heartbeat is not sent with interval of login response")
+	}
+	cancel()
+	<-done
+}
+
+func TestHeartbeatCurrentInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []HeartbeatOption
+		server   time.Duration
+		expected time.Duration
+	}{
+		{
+			name:     "client interval is shorter",
+			options:  []HeartbeatOption{WithClientInterval(2 * time.Minute)},
+			server:   10 * time.Minute,
+			expected: 2 * time.Minute,
+		},
+		{
+			name:     "server interval is shorter",
+			options:  []HeartbeatOption{WithClientInterval(2 * time.Minute)},
+			server:   time.Minute,
+			expected: time.Minute,
+		},
+		{
+			name:     "only server interval",
+			server:   10 * time.Minute,
+			expected: 10 * time.Minute,
+		},
+		{
+			name:     "adaptive starts from server interval",
+			options:  []HeartbeatOption{WithClientInterval(2 * time.Minute), WithAdaptive(true)},
+			server:   10 * time.Minute,
+			expected: 10 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHeartbeat(tt.options...)
+			h.configure(&HeartbeatConfig{Interval: tt.server})
+			if interval := h.currentInterval(); interval != tt.expected {
+				t.Fatalf("interval = %s, want %s", interval, tt.expected)
+			}
+		})
+	}
+}
@@ -71,7 +71,7 @@ func (c *Client) checkIn(ctx context.Context, opt *checkInOption) (resp *pb.Andr
 		return nil, errors.Wrap(err, "marshal GCM checkin request")
 	}
 
-	res, err := c.post(ctx, checkinURL, bytes.NewReader(message), func(header *http.Header) {
+	res, err := c.post(ctx, c.endpoints.Checkin, bytes.NewReader(message), func(header *http.Header) {
 		header.Set("Content-Type", "application/x-protobuf")
 	})
 	if err != nil {
@@ -108,7 +108,7 @@ func (c *Client) doRegister(ctx context.Context, androidID int64, securityToken
 	values.Set("device", device)
 	values.Set("sender", c.vapidKey)
 
-	res, err := c.post(ctx, registerURL, strings.NewReader(values.Encode()), func(header *http.Header) {
+	res, err := c.post(ctx, c.endpoints.Register, strings.NewReader(values.Encode()), func(header *http.Header) {
 		header.Set("Content-Type", "application/x-www-form-urlencoded")
 		header.Set("Authorization", fmt.Sprintf("AidLogin %s:%s", device, strconv.FormatUint(securityToken, 10)))
 		header.Set("User-Agent", "")
@@ -135,3 +135,43 @@ func (c *Client) doRegister(ctx context.Context, androidID int64, securityToken
 		securityToken: securityToken,
 	}, nil
 }
+
+func (c *Client) unregisterGCM(ctx context.Context, androidID int64, securityToken uint64) error {
+	device := strconv.FormatInt(androidID, 10)
+
+	values := url.Values{}
+	values.Set("app", "org.chromium.linux")
+	values.Set("X-subtype", c.appID)
+	values.Set("device", device)
+	values.Set("delete", "true")
+
+	res, err := c.post(ctx, c.endpoints.Register, strings.NewReader(values.Encode()), func(header *http.Header) {
+		header.Set("Content-Type", "application/x-www-form-urlencoded")
+		header.Set("Authorization", fmt.Sprintf("AidLogin %s:%s", device, strconv.FormatUint(securityToken, 10)))
+		header.Set("User-Agent", "")
+	})
+	if err != nil {
+		return errors.Wrap(err, "request GCM unregister")
+	}
+	defer closeResponse(c.logger, res)
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return ErrGcmAuthorization
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("server error: %s", res.Status)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(err, "read GCM unregister response")
+	}
+
+	result, err := url.ParseQuery(string(data))
+	if err != nil {
+		return errors.Wrap(err, "parse GCM unregister response")
+	}
+	if reason := result.Get("Error"); len(reason) > 0 {
+		return errors.Errorf("GCM unregister error: %s", reason)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"iter"
+)
+
+// All subscribes to FCM, and returns iterator of all events.
+// Breaking the loop cancels the subscription, and waits for the connection to be closed.
+// Messages are acknowledged when yielded, and messages not yielded yet are redelivered by FCM later.
+// Events is consumed by the iterator, so it can be used only once, and not with WithHandler.
+func (c *Client) All(ctx context.Context) iter.Seq[Event] {
+	return func(yield func(Event) bool) {
+		_ = c.iterate(ctx, yield)
+	}
+}
+
+// Messages subscribes to FCM, and returns iterator of received messages.
+// Connection errors are yielded with nil message while the client retries,
+// and the error which stopped retrying is yielded last when WithRetry(false).
+// Breaking the loop cancels the subscription, and waits for the connection to be closed.
+// Messages are acknowledged when yielded, and messages not yielded yet are redelivered by FCM later.
+func (c *Client) Messages(ctx context.Context) iter.Seq2[*MessageEvent, error] {
+	return func(yield func(*MessageEvent, error) bool) {
+		err := c.iterate(ctx, func(event Event) bool {
+			switch ev := event.(type) {
+			case *MessageEvent:
+				return yield(ev, nil)
+			case *RetryEvent:
+				return yield(nil, ev.ErrorObj)
+			case *HeartbeatError:
+				return yield(nil, ev.ErrorObj)
+			case *UnauthorizedError:
+				return yield(nil, ev.ErrorObj)
+			case *AppError:
+				return yield(nil, ev.ErrorObj)
+			case *DecryptionFailedEvent:
+				return yield(nil, ev.ErrorObj)
+			default:
+				return true
+			}
+		})
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// iterate runs subscribe, and passes events to yield until it returns false.
+// Messages are acknowledged after yield unless WithManualAck is enabled.
+// Messages received but not yielded when the loop is broken are left unacknowledged,
+// and FCM redelivers them on next subscription. They are counted in EventStats.DroppedMessages.
+// It returns the error which stopped subscribe, or nil when the loop is broken.
+func (c *Client) iterate(ctx context.Context, yield func(Event) bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c.iterAck = true
+	done := make(chan error, 1)
+	go func() {
+		done <- c.subscribe(ctx)
+	}()
+
+	for event := range c.Events {
+		next := yield(event)
+		if ev, ok := event.(*MessageEvent); ok && !c.manualAck {
+			ev.Ack()
+		}
+		if !next {
+			cancel()
+			// drain events emitted before cancel until subscribe closes Events.
+			for event := range c.Events {
+				if ev, ok := event.(*MessageEvent); ok {
+					c.eventCounters.droppedMessages.Add(1)
+					c.logger.Warn("message is not yielded, left unacknowledged", "persistentID", ev.PersistentID)
+				}
+			}
+			<-done
+			return nil
+		}
+	}
+	return <-done
+}
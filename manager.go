@@ -0,0 +1,275 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrManagerClosed is error that the manager is closed.
+var ErrManagerClosed = errors.New("manager closed")
+
+// ErrIdentityExists is error that the identity is running already.
+var ErrIdentityExists = errors.New("identity already started")
+
+// ErrIdentityNotFound is error that the identity is not running.
+var ErrIdentityNotFound = errors.New("identity not found")
+
+// ManagedEvent is event of a client tagged with its identity key.
+type ManagedEvent struct {
+	Key   string
+	Event Event
+}
+
+// managedClient is a client running in Manager.
+type managedClient struct {
+	client *Client
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager runs many FCM identities in one process.
+// Clients share the HTTP client, the dialer and the backoff policy,
+// and the number of concurrent registrations and connects is limited.
+type Manager struct {
+	options       []ClientOption
+	backoffBase   time.Duration
+	backoffMax    time.Duration
+	maxConcurrent int
+	bufferSize    int
+
+	events  chan *ManagedEvent
+	limiter chan struct{}
+	closed  chan struct{}
+
+	mu        sync.Mutex
+	clients   map[string]*managedClient
+	forwarder sync.WaitGroup
+	isClosed  bool
+}
+
+// ManagerOption type
+type ManagerOption func(*Manager)
+
+// WithClientOptions is options shared by all clients setter
+func WithClientOptions(options ...ClientOption) ManagerOption {
+	return func(m *Manager) {
+		m.options = append(m.options, options...)
+	}
+}
+
+// WithSharedBackoff is backoff policy of all clients setter
+func WithSharedBackoff(base time.Duration, max time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.backoffBase = base
+		m.backoffMax = max
+	}
+}
+
+// WithMaxConcurrentConnects is maximum number of concurrent registrations and connects setter
+func WithMaxConcurrentConnects(n int) ManagerOption {
+	return func(m *Manager) {
+		m.maxConcurrent = n
+	}
+}
+
+// WithManagerEventBuffer is buffer size of merged event channel setter
+func WithManagerEventBuffer(size int) ManagerOption {
+	return func(m *Manager) {
+		m.bufferSize = size
+	}
+}
+
+// NewManager creates Manager instance.
+func NewManager(options ...ManagerOption) *Manager {
+	m := &Manager{
+		backoffBase:   defaultBackoffBase * time.Second,
+		backoffMax:    defaultBackoffMax * time.Second,
+		maxConcurrent: defaultMaxConcurrentConnects,
+		bufferSize:    defaultManagerEventBuffer,
+		closed:        make(chan struct{}),
+		clients:       make(map[string]*managedClient),
+	}
+	for _, option := range options {
+		option(m)
+	}
+
+	m.events = make(chan *ManagedEvent, m.bufferSize)
+	if m.maxConcurrent > 0 {
+		m.limiter = make(chan struct{}, m.maxConcurrent)
+	}
+
+	// share connection pool and dialer among clients unless they are given.
+	shared := []ClientOption{
+		WithHTTPClient(&http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: false,
+					MinVersion:         tls.VersionTLS13,
+				},
+			},
+		}),
+		WithDialer(&net.Dialer{
+			Timeout:       defaultDialTimeout * time.Second,
+			KeepAlive:     defaultKeepAlive * time.Minute,
+			FallbackDelay: 30 * time.Millisecond,
+		}),
+	}
+	m.options = append(shared, m.options...)
+
+	return m
+}
+
+// Events returns merged event channel of all clients.
+// It is closed by Close.
+func (m *Manager) Events() <-chan *ManagedEvent {
+	return m.events
+}
+
+// Start creates a client of the identity, and subscribes to FCM until Stop or Close.
+func (m *Manager) Start(ctx context.Context, key string, config *Config, options ...ClientOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.isClosed {
+		return ErrManagerClosed
+	}
+	if _, ok := m.clients[key]; ok {
+		return errors.Wrap(ErrIdentityExists, key)
+	}
+
+	clientOptions := slices.Concat(m.options, []ClientOption{
+		WithBackoff(NewBackoff(m.backoffBase, m.backoffMax)),
+		withConnectLimiter(m.limiter),
+	}, options)
+	client, err := NewClient(config, clientOptions...)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	managed := &managedClient{
+		client: client,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.clients[key] = managed
+
+	go func() {
+		defer close(managed.done)
+		client.Subscribe(ctx)
+	}()
+
+	m.forwarder.Go(func() {
+		m.forward(key, client.Events)
+	})
+
+	// remove the identity when subscribe stopped by itself.
+	go func() {
+		<-managed.done
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.clients[key] == managed {
+			delete(m.clients, key)
+		}
+	}()
+	return nil
+}
+
+// Stop stops the client of the identity, and waits for it.
+func (m *Manager) Stop(key string) error {
+	m.mu.Lock()
+	managed, ok := m.clients[key]
+	if ok {
+		delete(m.clients, key)
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return errors.Wrap(ErrIdentityNotFound, key)
+	}
+	managed.cancel()
+	<-managed.done
+	return nil
+}
+
+// Client returns the client of the identity.
+func (m *Manager) Client(key string) (*Client, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	managed, ok := m.clients[key]
+	if !ok {
+		return nil, false
+	}
+	return managed.client, true
+}
+
+// Keys returns identity keys of running clients.
+func (m *Manager) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.clients))
+	for key := range m.clients {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Close stops all clients, and closes the merged event channel.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	if m.isClosed {
+		m.mu.Unlock()
+		return
+	}
+	m.isClosed = true
+	clients := m.clients
+	m.clients = make(map[string]*managedClient)
+	m.mu.Unlock()
+
+	for _, managed := range clients {
+		managed.cancel()
+	}
+	for _, managed := range clients {
+		<-managed.done
+	}
+
+	// events not consumed yet are dropped.
+	close(m.closed)
+	m.forwarder.Wait()
+	close(m.events)
+}
+
+// forward sends events of a client to the merged event channel.
+func (m *Manager) forward(key string, events <-chan Event) {
+	for event := range events {
+		select {
+		case m.events <- &ManagedEvent{Key: key, Event: event}:
+		case <-m.closed:
+		}
+	}
+}
+
+// withConnectLimiter is semaphore of concurrent registrations and connects setter
+func withConnectLimiter(limiter chan struct{}) ClientOption {
+	return func(client *Client) {
+		client.connectLimiter = limiter
+	}
+}
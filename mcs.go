@@ -8,52 +8,70 @@
 package pushreceiver
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"fmt"
-	"io"
 	"log/slog"
 	"strconv"
 	"sync"
+	"time"
 
 	pb "github.com/crow-misia/go-push-receiver/pb/mcs"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/encoding/protojson"
-	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
 type mcs struct {
-	conn             *tls.Conn
-	logger           *slog.Logger
-	creds            *FCMCredentials
-	incomingStreamId int32
-	heartbeatAck     chan bool
-	heartbeat        *Heartbeat
-	disconnectDm     sync.Once
-	events           chan Event
+	conn               *tls.Conn
+	addr               string
+	reader             *frameReader
+	logger             *slog.Logger
+	creds              *FCMCredentials
+	incomingStreamId   int32
+	outgoingStreamId   int32
+	streamMu           sync.Mutex
+	sendMu             sync.Mutex
+	ack                *streamAck
+	loginPersistentIds []string
+	heartbeatAck       chan bool
+	heartbeatSentAt    time.Time
+	heartbeat          *Heartbeat
+	disconnectDm       sync.Once
+	emit               func(Event)
 }
 
-func (c *Client) newMCS(conn *tls.Conn) *mcs {
+func (c *Client) newMCS(ctx context.Context, conn *tls.Conn, addr string) *mcs {
 	return &mcs{
 		conn:             conn,
+		addr:             addr,
+		reader:           newFrameReader(conn, c.maxFrameSize),
 		logger:           c.logger,
-		creds:            c.creds,
+		creds:            c.credentials(),
 		incomingStreamId: 0,
-		heartbeatAck:     make(chan bool),
-		heartbeat:        c.heartbeat,
-		events:           c.Events,
+		outgoingStreamId: 0,
+		ack: newStreamAck(c.ackThreshold, c.ackTimeout, func(persistentIds []string) {
+			c.confirmPersistentIDs(context.Background(), persistentIds)
+		}),
+		heartbeatAck: make(chan bool, 1),
+		heartbeat:    c.heartbeat,
+		emit: func(event Event) {
+			c.emit(ctx, event)
+		},
 	}
 }
 
 func (mcs *mcs) disconnect(reason string) {
 	mcs.disconnectDm.Do(func() {
-		close(mcs.heartbeatAck)
-		mcs.events <- &DisconnectedEvent{Reason: reason}
+		mcs.ack.stop()
+		mcs.emit(&DisconnectedEvent{Reason: reason})
 	})
 }
 
 func (mcs *mcs) SendLoginPacket(ctx context.Context, receivedPersistentId []string) error {
+	mcs.loginPersistentIds = receivedPersistentId
+
 	androidId := proto.String(strconv.FormatInt(mcs.creds.AndroidID, 10))
 
 	setting := []*pb.Setting{
@@ -83,103 +101,165 @@ func (mcs *mcs) SendLoginPacket(ctx context.Context, receivedPersistentId []stri
 		LastRmqId:            proto.Int64(1), // Sending not enabled yet so this stays as 1.
 		Setting:              setting,
 		AdaptiveHeartbeat:    proto.Bool(mcs.heartbeat.adaptive),
+		HeartbeatStat:        mcs.heartbeat.loginStat(),
 		ReceivedPersistentId: receivedPersistentId,
 	}
 
-	return mcs.sendRequest(ctx, tagLoginRequest, request, true)
+	_, err := mcs.sendRequest(ctx, tagLoginRequest, request, true)
+	return err
 }
 
 func (mcs *mcs) SendHeartbeatPingPacket(ctx context.Context) error {
-	streamId := mcs.incomingStreamId
 	request := &pb.HeartbeatPing{
-		LastStreamIdReceived: proto.Int32(streamId),
+		LastStreamIdReceived: proto.Int32(mcs.reportIncomingStreamId()),
+	}
+
+	sentAt := time.Now()
+	if _, err := mcs.sendRequest(ctx, tagHeartbeatPing, request, false); err != nil {
+		mcs.emit(&HeartbeatError{err})
+		return err
 	}
 
-	return mcs.sendRequest(ctx, tagHeartbeatPing, request, false)
+	mcs.streamMu.Lock()
+	mcs.heartbeatSentAt = sentAt
+	mcs.streamMu.Unlock()
+
+	mcs.emit(&HeartbeatEvent{
+		Send:                 true,
+		Ack:                  false,
+		LastStreamIDReceived: request.GetLastStreamIdReceived(),
+	})
+	return nil
 }
 
 func (mcs *mcs) SendHeartbeatAckPacket(ctx context.Context) error {
-	streamId := mcs.incomingStreamId
 	request := &pb.HeartbeatAck{
-		LastStreamIdReceived: proto.Int32(streamId),
+		LastStreamIdReceived: proto.Int32(mcs.reportIncomingStreamId()),
+	}
+
+	if _, err := mcs.sendRequest(ctx, tagHeartbeatAck, request, false); err != nil {
+		mcs.emit(&HeartbeatError{err})
+		return err
+	}
+
+	mcs.emit(&HeartbeatEvent{
+		Send:                 true,
+		Ack:                  true,
+		LastStreamIDReceived: request.GetLastStreamIdReceived(),
+	})
+	return nil
+}
+
+// SendStreamAckPacket sends IqStanza with StreamAck extension.
+func (mcs *mcs) SendStreamAckPacket(ctx context.Context) error {
+	data, err := proto.Marshal(&pb.StreamAck{})
+	if err != nil {
+		return errors.Wrap(err, "encode stream ack")
 	}
+	_, err = mcs.sendIqExtension(ctx, iqExtensionStreamAck, data)
+	return err
+}
 
-	return mcs.sendRequest(ctx, tagHeartbeatAck, request, false)
+// SendSelectiveAckPacket sends IqStanza with SelectiveAck extension, and returns its stream id.
+func (mcs *mcs) SendSelectiveAckPacket(ctx context.Context, persistentIds []string) (int32, error) {
+	data, err := proto.Marshal(&pb.SelectiveAck{Id: persistentIds})
+	if err != nil {
+		return 0, errors.Wrap(err, "encode selective ack")
+	}
+	return mcs.sendIqExtension(ctx, iqExtensionSelectiveAck, data)
 }
 
-func (mcs *mcs) sendRequest(ctx context.Context, tag tagType, request proto.Message, containVersion bool) error {
-	header := make([]byte, 0, 100)
-	if containVersion {
-		header = append(header, fcmVersion, byte(tag))
-	} else {
-		header = append(header, byte(tag))
+func (mcs *mcs) sendIqExtension(ctx context.Context, extensionId int32, data []byte) (int32, error) {
+	request := &pb.IqStanza{
+		Type: pb.IqStanza_SET.Enum(),
+		Id:   proto.String(""),
+		Extension: &pb.Extension{
+			Id:   proto.Int32(extensionId),
+			Data: data,
+		},
+		LastStreamIdReceived: proto.Int32(mcs.reportIncomingStreamId()),
 	}
 
+	return mcs.sendRequest(ctx, tagIqStanza, request, false)
+}
+
+// sendRequest sends request, and returns the outgoing stream id of it.
+func (mcs *mcs) sendRequest(ctx context.Context, tag tagType, request proto.Message, containVersion bool) (int32, error) {
 	if mcs.logger.Enabled(ctx, slog.LevelDebug) {
 		mcs.logger.Debug("MCS request", "tag", tag, "message", protojson.MarshalOptions{Multiline: false}.Format(request))
 	}
 
-	requestSize := proto.Size(request)
-	if requestSize < 0 {
-		return fmt.Errorf("invalid request size %d", requestSize)
-	}
-	header = protowire.AppendVarint(header, uint64(requestSize))
-	data, err := proto.Marshal(request)
+	buf := getFrameBuffer()
+	defer putFrameBuffer(buf)
+
+	frame, err := appendFrame(*buf, tag, request, containVersion)
+	*buf = frame
 	if err != nil {
-		return errors.Wrap(err, "encode protocol buffer data")
+		return 0, err
 	}
 
+	mcs.sendMu.Lock()
+	defer mcs.sendMu.Unlock()
+
 	// output request
-	_, err = mcs.conn.Write(append(header, data...))
-	return err
+	if _, err = mcs.conn.Write(frame); err != nil {
+		return 0, err
+	}
+
+	mcs.streamMu.Lock()
+	defer mcs.streamMu.Unlock()
+	mcs.outgoingStreamId++
+	return mcs.outgoingStreamId, nil
 }
 
 func (mcs *mcs) ReceiveVersion() error {
-	buf := make([]byte, versionPacketLen)
-	length, err := io.ReadFull(mcs.conn, buf)
+	if err := mcs.setReadDeadline(); err != nil {
+		return errors.Wrap(err, "set read deadline")
+	}
+
+	version, err := mcs.reader.readVersion()
 	if err != nil {
 		return errors.Wrap(err, "receive version packet")
 	}
-	if length != versionPacketLen || buf[0] != fcmVersion {
-		return errors.Errorf("Version do not match. Received %d, Expecting %d", buf[0], fcmVersion)
+	if version != fcmVersion {
+		return errors.Errorf("Version do not match. Received %d, Expecting %d", version, fcmVersion)
 	}
 	return nil
 }
 
 func (mcs *mcs) PerformReadTag(ctx context.Context) (proto.Message, error) {
-	var err error
-
-	// receive tag
-	tag, err := mcs.receiveTag()
-	if err != nil {
-		return nil, errors.Wrap(err, "receive tag packet")
-	}
-
-	// receive size
-	size, err := mcs.receiveSize()
-	if err != nil {
-		return nil, errors.Wrap(err, "receive size packet")
+	// the server sends heartbeat at least within deadman timeout.
+	if err := mcs.setReadDeadline(); err != nil {
+		return nil, errors.Wrap(err, "set read deadline")
 	}
 
-	// receive data
-	buf := make([]byte, size)
-	_, err = io.ReadFull(mcs.conn, buf)
+	tag, buf, err := mcs.reader.readFrame()
 	if err != nil {
-		return nil, errors.Wrap(err, "receive data packet")
+		return nil, err
 	}
+	defer putFrameBuffer(buf)
 
-	return mcs.UnmarshalTagData(ctx, tag, buf)
+	return mcs.UnmarshalTagData(ctx, tag, *buf)
 }
 
 func (mcs *mcs) UnmarshalTagData(ctx context.Context, tag tagType, buf []byte) (proto.Message, error) {
 	receive := tag.GenerateMessage()
 	if receive == nil {
-		return nil, errors.Errorf("unknown tag: %x", tag)
+		// skip the stanza which has no definition, the size is known already.
+		mcs.receivedStanza()
+		mcs.logger.Debug("MCS receive unknown stanza", "tag", tag, "size", len(buf))
+		mcs.emit(&RawStanzaEvent{
+			Tag:  uint8(tag),
+			Name: tag.String(),
+			Data: bytes.Clone(buf),
+		})
+		return nil, nil
 	}
 
 	if err := proto.Unmarshal(buf, receive); err != nil {
 		return receive, errors.Wrapf(err, "unmarshal tag(%x) data", tag)
 	}
+	mcs.receivedStanza()
 
 	// output receive
 	if mcs.logger.Enabled(ctx, slog.LevelDebug) {
@@ -195,55 +275,117 @@ func (mcs *mcs) UnmarshalTagData(ctx context.Context, tag tagType, buf []byte) (
 }
 
 func (mcs *mcs) handleTag(ctx context.Context, receive proto.Message) error {
+	// the server reports the last stream id it received from us.
+	if data, ok := receive.(interface{ GetLastStreamIdReceived() int32 }); ok {
+		mcs.ack.confirm(data.GetLastStreamIdReceived())
+	}
+
 	switch data := receive.(type) {
 	case *pb.HeartbeatPing:
-		mcs.updateIncomingStreamId(data.GetLastStreamIdReceived())
-		mcs.heartbeatAck <- true
+		mcs.notifyHeartbeatAck()
+		mcs.emit(&HeartbeatEvent{
+			Send:                 false,
+			Ack:                  false,
+			Status:               data.GetStatus(),
+			LastStreamIDReceived: data.GetLastStreamIdReceived(),
+		})
 		return mcs.SendHeartbeatAckPacket(ctx)
 	case *pb.HeartbeatAck:
-		mcs.updateIncomingStreamId(data.GetLastStreamIdReceived())
-		mcs.heartbeatAck <- true
-	case *pb.LoginResponse:
-		mcs.updateIncomingStreamId(data.GetLastStreamIdReceived())
-	case *pb.IqStanza:
-		mcs.updateIncomingStreamId(data.GetLastStreamIdReceived())
+		mcs.notifyHeartbeatAck()
+
+		var roundTrip time.Duration
+		mcs.streamMu.Lock()
+		if !mcs.heartbeatSentAt.IsZero() {
+			roundTrip = time.Since(mcs.heartbeatSentAt)
+			mcs.heartbeatSentAt = time.Time{}
+		}
+		mcs.streamMu.Unlock()
+
+		mcs.emit(&HeartbeatEvent{
+			Send:                 false,
+			Ack:                  true,
+			Status:               data.GetStatus(),
+			LastStreamIDReceived: data.GetLastStreamIdReceived(),
+			RoundTrip:            roundTrip,
+		})
 	}
 	return nil
 }
 
-func (mcs *mcs) updateIncomingStreamId(lastStreamIdReceived int32) {
-	if lastStreamIdReceived > 0 {
-		mcs.incomingStreamId = lastStreamIdReceived
+// notifyHeartbeatAck notifies the heartbeat that the connection is alive.
+// It does not block when the heartbeat has a pending notification or has stopped.
+func (mcs *mcs) notifyHeartbeatAck() {
+	select {
+	case mcs.heartbeatAck <- true:
+	default:
 	}
 }
 
-func (mcs *mcs) receiveTag() (tagType, error) {
-	buf := make([]byte, tagPacketLen)
-	n, err := io.ReadFull(mcs.conn, buf)
-	if err != nil {
-		return tagUnknown, err
-	}
-	if n == 0 {
-		return tagUnknown, io.ErrClosedPipe
-	}
-	return tagType(buf[0]), nil
+// receivedStanza counts up incoming stream id.
+func (mcs *mcs) receivedStanza() {
+	mcs.streamMu.Lock()
+	defer mcs.streamMu.Unlock()
+	mcs.incomingStreamId++
+}
+
+// reportIncomingStreamId returns incoming stream id to notify to the server as last_stream_id_received.
+func (mcs *mcs) reportIncomingStreamId() int32 {
+	mcs.streamMu.Lock()
+	defer mcs.streamMu.Unlock()
+	mcs.ack.reported(mcs.incomingStreamId)
+	return mcs.incomingStreamId
 }
 
-func (mcs *mcs) receiveSize() (uint64, error) {
-	offset := 0
-	buf := make([]byte, sizePacketLenMax)
-	for {
-		if offset >= sizePacketLenMax {
-			return 0, io.ErrUnexpectedEOF
+// queueAck queues the persistent id to acknowledge, and flushes acks when there are too many unacknowledged messages.
+func (mcs *mcs) queueAck(ctx context.Context, persistentId string) error {
+	mcs.streamMu.Lock()
+	unacked := mcs.incomingStreamId
+	mcs.streamMu.Unlock()
+
+	if !mcs.ack.add(persistentId, unacked, func() {
+		if err := mcs.flushAck(ctx); err != nil {
+			mcs.logger.Debug("failed to send stream ack", slog.Any("error", err))
 		}
-		length, err := mcs.conn.Read(buf[offset : offset+1])
+	}) {
+		return nil
+	}
+	return mcs.flushAck(ctx)
+}
+
+// flushAck sends SelectiveAck for queued persistent ids and StreamAck.
+func (mcs *mcs) flushAck(ctx context.Context) error {
+	ids := mcs.ack.take()
+	if len(ids) > 0 {
+		streamId, err := mcs.SendSelectiveAckPacket(ctx, ids)
 		if err != nil {
-			return 0, err
-		}
-		offset += length
-		v, n := protowire.ConsumeVarint(buf[0:offset])
-		if n > 0 {
-			return v, nil
+			return errors.Wrap(err, "send selective ack")
 		}
+		mcs.ack.sent(streamId, ids)
+	}
+	if err := mcs.SendStreamAckPacket(ctx); err != nil {
+		return errors.Wrap(err, "send stream ack")
+	}
+	return nil
+}
+
+// flushAckOnClose sends queued acks with short write deadline before the socket is closed.
+func (mcs *mcs) flushAckOnClose(ctx context.Context) {
+	if !mcs.ack.hasQueued() {
+		return
+	}
+	if err := mcs.conn.SetWriteDeadline(time.Now().Add(ackFlushTimeout * time.Second)); err != nil {
+		return
+	}
+	if err := mcs.flushAck(ctx); err != nil {
+		mcs.logger.Debug("failed to flush stream ack on close", slog.Any("error", err))
+	}
+}
+
+// setReadDeadline sets read deadline of the next frame by heartbeat deadman timeout.
+func (mcs *mcs) setReadDeadline() error {
+	timeout := mcs.heartbeat.deadman()
+	if timeout <= 0 {
+		return nil
 	}
+	return mcs.conn.SetReadDeadline(time.Now().Add(timeout))
 }
@@ -11,6 +11,7 @@ import (
 	"crypto/tls"
 	"log/slog"
 	"net"
+	"time"
 )
 
 // ClientOption type
@@ -30,13 +31,70 @@ func WithCreds(creds *FCMCredentials) ClientOption {
 	}
 }
 
+// WithApps is additional apps sharing the device checkin and the MCS connection setter
+func WithApps(configs ...*Config) ClientOption {
+	return func(client *Client) {
+		client.apps = append(client.apps, configs...)
+	}
+}
+
+// WithCredentialStore is CredentialStore setter
+func WithCredentialStore(store CredentialStore) ClientOption {
+	return func(client *Client) {
+		client.credentialStore = store
+	}
+}
+
+// WithPersistentIDStore is PersistentIDStore setter
+func WithPersistentIDStore(store PersistentIDStore) ClientOption {
+	return func(client *Client) {
+		client.persistentIDStore = store
+	}
+}
+
 // WithReceivedPersistentID is received persistentID list setter
+// The ids are added to the PersistentIDStore as acknowledged.
 func WithReceivedPersistentID(ids []string) ClientOption {
 	return func(client *Client) {
 		client.receivedPersistentID = ids
 	}
 }
 
+// WithMaxFrameSize is maximum size of MCS frame setter
+func WithMaxFrameSize(size int) ClientOption {
+	return func(client *Client) {
+		client.maxFrameSize = size
+	}
+}
+
+// WithManualAck configures whether messages are acknowledged by MessageEvent.Ack instead of on receive.
+func WithManualAck(enabled bool) ClientOption {
+	return func(client *Client) {
+		client.manualAck = enabled
+	}
+}
+
+// WithAckThreshold is number of unacknowledged messages before sending stream ack setter
+func WithAckThreshold(count int) ClientOption {
+	return func(client *Client) {
+		client.ackThreshold = count
+	}
+}
+
+// WithAckTimeout is timeout before sending stream ack setter
+func WithAckTimeout(timeout time.Duration) ClientOption {
+	return func(client *Client) {
+		client.ackTimeout = timeout
+	}
+}
+
+// WithTokenRefresh is period of registration token refresh setter, 0 disables it.
+func WithTokenRefresh(period time.Duration) ClientOption {
+	return func(client *Client) {
+		client.tokenRefreshPeriod = period
+	}
+}
+
 // WithHTTPClient is http.Client setter
 func WithHTTPClient(c httpClient) ClientOption {
 	return func(client *Client) {
@@ -72,6 +130,21 @@ func WithDialer(dialer *net.Dialer) ClientOption {
 	}
 }
 
+// WithEndpoints is service endpoints setter
+func WithEndpoints(endpoints Endpoints) ClientOption {
+	return func(client *Client) {
+		client.endpoints = endpoints
+	}
+}
+
+// WithDialContext is custom dial function setter for MCS connection.
+// The proxy environment variables are not used with it.
+func WithDialContext(dial DialContextFunc) ClientOption {
+	return func(client *Client) {
+		client.dialContext = dial
+	}
+}
+
 // WithRetry configures whether to retry when an error occurs.
 func WithRetry(retry bool) ClientOption {
 	return func(client *Client) {
@@ -85,3 +158,36 @@ func WithEvents(events chan Event) ClientOption {
 		client.Events = events
 	}
 }
+
+// WithHandler is Handler setter.
+// Events are delivered to the handler instead of Events channel.
+func WithHandler(handler Handler) ClientOption {
+	return func(client *Client) {
+		client.handler = handler
+	}
+}
+
+// WithHandlerWorkers is number of workers calling Handler setter.
+// Handler is called in order of events only with 1 worker.
+func WithHandlerWorkers(workers int) ClientOption {
+	return func(client *Client) {
+		client.handlerWorkers = workers
+	}
+}
+
+// WithEventQueue is size and overflow policy of the event queue setter.
+// The default policy is OverflowDropOldest, which never blocks the connection by status events.
+func WithEventQueue(size int, policy OverflowPolicy) ClientOption {
+	return func(client *Client) {
+		client.eventQueueSize = size
+		client.overflowPolicy = policy
+	}
+}
+
+// WithSpillFile is file for OverflowSpill setter.
+// The file is truncated on Subscribe, and removed after Subscribe.
+func WithSpillFile(filename string) ClientOption {
+	return func(client *Client) {
+		client.spillFilename = filename
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Notification is notification of FCM Web Push payload.
+type Notification struct {
+	Title       string `json:"title,omitempty"`
+	Body        string `json:"body,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+	ClickAction string `json:"click_action,omitempty"`
+	Image       string `json:"image,omitempty"`
+}
+
+// WebPushPayload is decoded FCM Web Push payload.
+// Non-string values of Data are kept as JSON text.
+type WebPushPayload struct {
+	Notification *Notification     `json:"notification,omitempty"`
+	Data         map[string]string `json:"-"`
+	FCMMessageID string            `json:"fcmMessageId,omitempty"`
+	From         string            `json:"from,omitempty"`
+	CollapseKey  string            `json:"collapse_key,omitempty"`
+	Priority     string            `json:"priority,omitempty"`
+
+	// Raw is the decrypted payload, which is set even when it is not JSON.
+	Raw []byte `json:"-"`
+
+	isJSON bool
+}
+
+// IsJSON reports whether the payload is decoded from JSON.
+func (p *WebPushPayload) IsJSON() bool {
+	return p.isJSON
+}
+
+// ParsePayload decodes FCM Web Push payload.
+// Payload which is not JSON object is returned only with Raw, and error is returned only for malformed JSON object.
+func ParsePayload(data []byte) (*WebPushPayload, error) {
+	payload := &WebPushPayload{Raw: data}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return payload, nil
+	}
+
+	var raw struct {
+		*WebPushPayload
+		Data map[string]json.RawMessage `json:"data,omitempty"`
+	}
+	raw.WebPushPayload = payload
+	if err := json.Unmarshal(trimmed, &raw); err != nil {
+		return &WebPushPayload{Raw: data}, errors.Wrap(err, "unmarshal web push payload")
+	}
+
+	payload.isJSON = true
+	if raw.Data != nil {
+		payload.Data = make(map[string]string, len(raw.Data))
+		for key, value := range raw.Data {
+			var s string
+			if err := json.Unmarshal(value, &s); err == nil {
+				payload.Data[key] = s
+			} else {
+				payload.Data[key] = string(value)
+			}
+		}
+	}
+	return payload, nil
+}
+
+// Payload returns decoded payload of the message, which is decoded once on first call.
+func (e *MessageEvent) Payload() (*WebPushPayload, error) {
+	e.payloadOnce.Do(func() {
+		e.payload, e.payloadErr = ParsePayload(e.Data)
+	})
+	return e.payload, e.payloadErr
+}
@@ -0,0 +1,339 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PersistentIDStore stores persistent ids of received messages.
+// Acknowledged ids are reported to FCM on login until they are confirmed,
+// and they are kept to suppress duplicated messages within the retention window.
+type PersistentIDStore interface {
+	// Received reports whether the message of the persistent id has been acknowledged already.
+	Received(ctx context.Context, persistentID string) (bool, error)
+	// Acked records the persistent id acknowledged.
+	Acked(ctx context.Context, persistentID string) error
+	// Confirmed records the persistent ids which FCM confirmed.
+	Confirmed(ctx context.Context, persistentIDs []string) error
+	// Pending returns the persistent ids acknowledged but not confirmed yet.
+	Pending(ctx context.Context) ([]string, error)
+}
+
+// persistentIDEntry is state of a persistent id.
+type persistentIDEntry struct {
+	ackedAt   time.Time
+	confirmed bool
+}
+
+// persistentIDSet keeps persistent ids in memory.
+type persistentIDSet struct {
+	retention time.Duration
+	entries   map[string]*persistentIDEntry
+}
+
+func newPersistentIDSet(retention time.Duration) *persistentIDSet {
+	return &persistentIDSet{
+		retention: retention,
+		entries:   make(map[string]*persistentIDEntry),
+	}
+}
+
+func (s *persistentIDSet) received(persistentID string, now time.Time) bool {
+	entry, ok := s.entries[persistentID]
+	return ok && !s.expired(entry, now)
+}
+
+func (s *persistentIDSet) acked(persistentID string, ackedAt time.Time) {
+	if entry, ok := s.entries[persistentID]; ok {
+		entry.ackedAt = ackedAt
+		return
+	}
+	s.entries[persistentID] = &persistentIDEntry{ackedAt: ackedAt}
+}
+
+func (s *persistentIDSet) confirmed(persistentID string) {
+	if entry, ok := s.entries[persistentID]; ok {
+		entry.confirmed = true
+	}
+}
+
+func (s *persistentIDSet) pending() []string {
+	ids := make([]string, 0, len(s.entries))
+	for id, entry := range s.entries {
+		if !entry.confirmed {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// prune removes entries which are out of the retention window.
+// Entries never confirmed are expired as well, so that they are not reported on every login.
+func (s *persistentIDSet) prune(now time.Time) {
+	for id, entry := range s.entries {
+		if s.expired(entry, now) {
+			delete(s.entries, id)
+		}
+	}
+}
+
+func (s *persistentIDSet) expired(entry *persistentIDEntry, now time.Time) bool {
+	return s.retention > 0 && now.Sub(entry.ackedAt) > s.retention
+}
+
+// MemoryPersistentIDStore stores persistent ids in memory.
+type MemoryPersistentIDStore struct {
+	set *persistentIDSet
+	mu  sync.Mutex
+}
+
+// NewMemoryPersistentIDStore creates MemoryPersistentIDStore instance.
+// Acknowledged ids are kept for retention to suppress duplicated messages.
+func NewMemoryPersistentIDStore(retention time.Duration) *MemoryPersistentIDStore {
+	return &MemoryPersistentIDStore{
+		set: newPersistentIDSet(retention),
+	}
+}
+
+// Received reports whether the message of the persistent id has been acknowledged already.
+func (s *MemoryPersistentIDStore) Received(_ context.Context, persistentID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.set.received(persistentID, time.Now()), nil
+}
+
+// Acked records the persistent id acknowledged.
+func (s *MemoryPersistentIDStore) Acked(_ context.Context, persistentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set.acked(persistentID, time.Now())
+	return nil
+}
+
+// Confirmed records the persistent ids which FCM confirmed.
+func (s *MemoryPersistentIDStore) Confirmed(_ context.Context, persistentIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range persistentIDs {
+		s.set.confirmed(id)
+	}
+	s.set.prune(time.Now())
+	return nil
+}
+
+// Pending returns the persistent ids acknowledged but not confirmed yet.
+func (s *MemoryPersistentIDStore) Pending(_ context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set.prune(time.Now())
+	return s.set.pending(), nil
+}
+
+// Persistent id log operations.
+const (
+	persistentIDOpAcked     = "A"
+	persistentIDOpConfirmed = "C"
+)
+
+// FilePersistentIDStore stores persistent ids in an append-only file.
+// The file is compacted when it has grown enough compared with live entries.
+type FilePersistentIDStore struct {
+	filename string
+	set      *persistentIDSet
+	file     *os.File
+	records  int
+	mu       sync.Mutex
+}
+
+// NewFilePersistentIDStore opens FilePersistentIDStore, and loads persistent ids from the file.
+// A file of one persistent id per line written by older version is imported as acknowledged ids.
+// Acknowledged ids are kept for retention to suppress duplicated messages.
+func NewFilePersistentIDStore(filename string, retention time.Duration) (*FilePersistentIDStore, error) {
+	s := &FilePersistentIDStore{
+		filename: filename,
+		set:      newPersistentIDSet(retention),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.set.prune(time.Now())
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FilePersistentIDStore) load() error {
+	f, err := os.Open(s.filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "open persistent id file")
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	loadedAt := time.Now()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// line format: "<op> <unix nano> <persistent id>"
+		fields := strings.SplitN(scanner.Text(), " ", 3)
+		if len(fields) == 1 && len(fields[0]) > 0 {
+			// legacy format of one persistent id per line, which is acknowledged but not confirmed.
+			s.set.acked(fields[0], loadedAt)
+			continue
+		}
+		if len(fields) != 3 {
+			continue
+		}
+		nanos, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case persistentIDOpAcked:
+			s.set.acked(fields[2], time.Unix(0, nanos))
+		case persistentIDOpConfirmed:
+			s.set.confirmed(fields[2])
+		}
+	}
+	return errors.Wrap(scanner.Err(), "read persistent id file")
+}
+
+// Received reports whether the message of the persistent id has been acknowledged already.
+func (s *FilePersistentIDStore) Received(_ context.Context, persistentID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.set.received(persistentID, time.Now()), nil
+}
+
+// Acked records the persistent id acknowledged.
+func (s *FilePersistentIDStore) Acked(_ context.Context, persistentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.set.acked(persistentID, now)
+	if err := s.append(persistentIDOpAcked, now, persistentID); err != nil {
+		return err
+	}
+	return s.sync()
+}
+
+// Confirmed records the persistent ids which FCM confirmed.
+func (s *FilePersistentIDStore) Confirmed(_ context.Context, persistentIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range persistentIDs {
+		s.set.confirmed(id)
+		if err := s.append(persistentIDOpConfirmed, now, id); err != nil {
+			return err
+		}
+	}
+	if err := s.sync(); err != nil {
+		return err
+	}
+
+	s.set.prune(now)
+	if s.records > len(s.set.entries)*2+persistentIDCompactionMin {
+		return s.compact()
+	}
+	return nil
+}
+
+// Pending returns the persistent ids acknowledged but not confirmed yet.
+func (s *FilePersistentIDStore) Pending(_ context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set.prune(time.Now())
+	return s.set.pending(), nil
+}
+
+// Close closes the file.
+func (s *FilePersistentIDStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FilePersistentIDStore) append(op string, at time.Time, persistentID string) error {
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	if _, err := fmt.Fprintf(s.file, "%s %d %s\n", op, at.UnixNano(), persistentID); err != nil {
+		return errors.Wrap(err, "append persistent id file")
+	}
+	s.records++
+	return nil
+}
+
+// sync flushes appended records to the disk for durable dedupe.
+func (s *FilePersistentIDStore) sync() error {
+	if err := s.file.Sync(); err != nil {
+		return errors.Wrap(err, "sync persistent id file")
+	}
+	return nil
+}
+
+// compact rewrites the file with live entries, and reopens it for appending.
+func (s *FilePersistentIDStore) compact() error {
+	var b strings.Builder
+	records := 0
+	for id, entry := range s.set.entries {
+		_, _ = fmt.Fprintf(&b, "%s %d %s\n", persistentIDOpAcked, entry.ackedAt.UnixNano(), id)
+		records++
+		if entry.confirmed {
+			_, _ = fmt.Fprintf(&b, "%s %d %s\n", persistentIDOpConfirmed, entry.ackedAt.UnixNano(), id)
+			records++
+		}
+	}
+
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return errors.Wrap(err, "close persistent id file")
+		}
+		s.file = nil
+	}
+	if err := writeFileAtomic(s.filename, []byte(b.String())); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.filename, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrap(err, "open persistent id file")
+	}
+	s.file = f
+	s.records = records
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFilePersistentIDStoreImportsLegacyFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "persistent_id.txt")
+	if err := os.WriteFile(filename, []byte("0:1%a\n0:2%b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFilePersistentIDStore(filename, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	pending, err := store.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(pending)
+	if want := []string{"0:1%a", "0:2%b"}; !slices.Equal(pending, want) {
+		t.Fatalf("pending = %v, want %v", pending, want)
+	}
+	if received, _ := store.Received(ctx, "0:1%a"); !received {
+		t.Fatal("legacy persistent id is not received")
+	}
+}
+
+func TestPersistentIDSetExpiresPending(t *testing.T) {
+	set := newPersistentIDSet(time.Hour)
+	now := time.Now()
+	set.acked("old", now.Add(-2*time.Hour))
+	set.acked("new", now)
+
+	set.prune(now)
+	if pending := set.pending(); !slices.Equal(pending, []string{"new"}) {
+		t.Fatalf("pending = %v, want [new]", pending)
+	}
+}
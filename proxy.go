@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SOCKS5 protocol constants.
+const (
+	socks5Version         = 0x05
+	socks5AuthNone        = 0x00
+	socks5AuthPassword    = 0x02
+	socks5AuthNoAccept    = 0xff
+	socks5PasswordVersion = 0x01
+	socks5CmdConnect      = 0x01
+	socks5AddrIPv4        = 0x01
+	socks5AddrDomain      = 0x03
+	socks5AddrIPv6        = 0x04
+	socks5ReplySucceeded  = 0x00
+)
+
+// proxyForAddr returns proxy URL for the address from the standard proxy environment variables.
+func proxyForAddr(addr string) (*url.URL, error) {
+	req := &http.Request{
+		URL: &url.URL{Scheme: "https", Host: addr},
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// dialProxy connects to addr through the proxy.
+func dialProxy(ctx context.Context, dial DialContextFunc, proxyURL *url.URL, addr string) (net.Conn, error) {
+	switch proxyURL.Scheme {
+	case "http", "https":
+		return dialHTTPConnect(ctx, dial, proxyURL, addr)
+	case "socks5", "socks5h":
+		return dialSOCKS5(ctx, dial, proxyURL, addr)
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme: %s", proxyURL.Scheme)
+	}
+}
+
+// proxyHostPort returns host:port of the proxy with default port of the scheme.
+func proxyHostPort(proxyURL *url.URL) string {
+	if port := proxyURL.Port(); len(port) > 0 {
+		return proxyURL.Host
+	}
+	switch proxyURL.Scheme {
+	case "https":
+		return net.JoinHostPort(proxyURL.Hostname(), "443")
+	case "socks5", "socks5h":
+		return net.JoinHostPort(proxyURL.Hostname(), "1080")
+	default:
+		return net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+}
+
+// withDeadline applies the deadline of ctx to conn while handshaking with the proxy.
+func withDeadline(ctx context.Context, conn net.Conn) func() {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	return func() {
+		stop()
+		_ = conn.SetDeadline(time.Time{})
+	}
+}
+
+// dialHTTPConnect connects to addr through HTTP CONNECT tunnel.
+func dialHTTPConnect(ctx context.Context, dial DialContextFunc, proxyURL *url.URL, addr string) (_ net.Conn, err error) {
+	conn, err := dial(ctx, "tcp", proxyHostPort(proxyURL))
+	if err != nil {
+		return nil, errors.Wrap(err, "dial proxy")
+	}
+	defer func() {
+		if err != nil {
+			_ = conn.Close()
+		}
+	}()
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName: proxyURL.Hostname(),
+			MinVersion: tls.VersionTLS12,
+		})
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, errors.Wrap(err, "TLS handshake with proxy")
+		}
+		conn = tlsConn
+	}
+	done := withDeadline(ctx, conn)
+	defer done()
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credential := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credential)
+	}
+	if err = req.Write(conn); err != nil {
+		return nil, errors.Wrap(err, "write CONNECT request")
+	}
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "read CONNECT response")
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("proxy error: %s", res.Status)
+	}
+	if br.Buffered() > 0 {
+		return nil, errors.New("proxy sent data before tunnel established")
+	}
+	return conn, nil
+}
+
+// dialSOCKS5 connects to addr through SOCKS5 proxy.
+func dialSOCKS5(ctx context.Context, dial DialContextFunc, proxyURL *url.URL, addr string) (_ net.Conn, err error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "split address")
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse port")
+	}
+	if len(host) > 255 {
+		return nil, errors.Errorf("too long host name: %s", host)
+	}
+
+	conn, err := dial(ctx, "tcp", proxyHostPort(proxyURL))
+	if err != nil {
+		return nil, errors.Wrap(err, "dial proxy")
+	}
+	defer func() {
+		if err != nil {
+			_ = conn.Close()
+		}
+	}()
+	done := withDeadline(ctx, conn)
+	defer done()
+
+	// greeting
+	methods := []byte{socks5AuthNone}
+	if proxyURL.User != nil {
+		methods = append(methods, socks5AuthPassword)
+	}
+	greeting := append([]byte{socks5Version, byte(len(methods))}, methods...)
+	if _, err = conn.Write(greeting); err != nil {
+		return nil, errors.Wrap(err, "write SOCKS5 greeting")
+	}
+	buf := make([]byte, 2)
+	if _, err = io.ReadFull(conn, buf); err != nil {
+		return nil, errors.Wrap(err, "read SOCKS5 greeting")
+	}
+	if buf[0] != socks5Version {
+		return nil, errors.Errorf("unexpected SOCKS version %d", buf[0])
+	}
+
+	switch buf[1] {
+	case socks5AuthNone:
+	case socks5AuthPassword:
+		if proxyURL.User == nil {
+			return nil, errors.New("SOCKS5 proxy requires authentication")
+		}
+		if err = socks5Authenticate(conn, proxyURL.User); err != nil {
+			return nil, err
+		}
+	case socks5AuthNoAccept:
+		return nil, errors.New("no acceptable SOCKS5 authentication method")
+	default:
+		return nil, errors.Errorf("unsupported SOCKS5 authentication method %d", buf[1])
+	}
+
+	// connect request
+	request := []byte{socks5Version, socks5CmdConnect, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		request = append(request, socks5AddrDomain, byte(len(host)))
+		request = append(request, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		request = append(request, socks5AddrIPv4)
+		request = append(request, ip4...)
+	} else {
+		request = append(request, socks5AddrIPv6)
+		request = append(request, ip.To16()...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err = conn.Write(request); err != nil {
+		return nil, errors.Wrap(err, "write SOCKS5 connect request")
+	}
+
+	// connect reply
+	reply := make([]byte, 4)
+	if _, err = io.ReadFull(conn, reply); err != nil {
+		return nil, errors.Wrap(err, "read SOCKS5 connect reply")
+	}
+	if reply[1] != socks5ReplySucceeded {
+		return nil, errors.Errorf("SOCKS5 connect failed with code %d", reply[1])
+	}
+	var skip int
+	switch reply[3] {
+	case socks5AddrIPv4:
+		skip = net.IPv4len
+	case socks5AddrIPv6:
+		skip = net.IPv6len
+	case socks5AddrDomain:
+		if _, err = io.ReadFull(conn, buf[:1]); err != nil {
+			return nil, errors.Wrap(err, "read SOCKS5 bound address")
+		}
+		skip = int(buf[0])
+	default:
+		return nil, errors.Errorf("unknown SOCKS5 address type %d", reply[3])
+	}
+	// bound address and port
+	if _, err = io.CopyN(io.Discard, conn, int64(skip+2)); err != nil {
+		return nil, errors.Wrap(err, "read SOCKS5 bound address")
+	}
+	return conn, nil
+}
+
+// socks5Authenticate performs username/password authentication (RFC 1929).
+func socks5Authenticate(conn net.Conn, user *url.Userinfo) error {
+	username := user.Username()
+	password, _ := user.Password()
+	if len(username) > 255 || len(password) > 255 {
+		return errors.New("too long SOCKS5 username or password")
+	}
+
+	request := []byte{socks5PasswordVersion, byte(len(username))}
+	request = append(request, username...)
+	request = append(request, byte(len(password)))
+	request = append(request, password...)
+	if _, err := conn.Write(request); err != nil {
+		return errors.Wrap(err, "write SOCKS5 authentication")
+	}
+
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return errors.Wrap(err, "read SOCKS5 authentication")
+	}
+	if buf[1] != 0x00 {
+		return errors.New("SOCKS5 authentication failed")
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// OverflowPolicy is behavior when the event queue is full.
+// MessageEvent, DecryptionFailedEvent, UpdateCredentialsEvent, UnauthorizedError and OptionError are never dropped by the policy,
+// the connection waits for room of the queue instead.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room of the queue for all events.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest status event in the queue.
+	OverflowDropOldest
+	// OverflowDropNewest drops the new status event.
+	OverflowDropNewest
+	// OverflowSpill spills messages to the file, and drops the oldest status event.
+	OverflowSpill
+)
+
+// String returns name of the policy.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowSpill:
+		return "spill"
+	default:
+		return "unknown"
+	}
+}
+
+// EventStats is counters of event delivery.
+type EventStats struct {
+	// Dropped is number of status events dropped.
+	Dropped uint64
+	// DroppedMessages is number of MessageEvent and DecryptionFailedEvent dropped on shutdown or spill failure,
+	// or left unacknowledged by iterators.
+	DroppedMessages uint64
+	// Spilled is number of MessageEvent spilled to the file.
+	Spilled uint64
+}
+
+// eventCounters is counters of event delivery kept across subscriptions.
+type eventCounters struct {
+	dropped         atomic.Uint64
+	droppedMessages atomic.Uint64
+	spilled         atomic.Uint64
+}
+
+// EventStats returns counters of event delivery.
+func (c *Client) EventStats() EventStats {
+	return EventStats{
+		Dropped:         c.eventCounters.dropped.Load(),
+		DroppedMessages: c.eventCounters.droppedMessages.Load(),
+		Spilled:         c.eventCounters.spilled.Load(),
+	}
+}
+
+// eventQueue is bounded queue between the connection and consumers of events.
+type eventQueue struct {
+	size     int
+	policy   OverflowPolicy
+	logger   *slog.Logger
+	counters *eventCounters
+	spill    *spillFile
+
+	mu      sync.Mutex
+	events  []Event
+	changed chan struct{}
+	closed  bool
+}
+
+func newEventQueue(size int, policy OverflowPolicy, spillFilename string, logger *slog.Logger, counters *eventCounters) (*eventQueue, error) {
+	q := &eventQueue{
+		size:     size,
+		policy:   policy,
+		logger:   logger,
+		counters: counters,
+		events:   make([]Event, 0, size),
+		changed:  make(chan struct{}),
+	}
+	if policy == OverflowSpill {
+		spill, err := openSpillFile(spillFilename)
+		if err != nil {
+			return nil, err
+		}
+		q.spill = spill
+	}
+	return q, nil
+}
+
+// push queues the event by the overflow policy, or drops it when ctx is done while waiting.
+func (q *eventQueue) push(ctx context.Context, event Event) {
+	message, isMessage := event.(*MessageEvent)
+	protected := !droppable(event)
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			q.drop(event, "queue closed")
+			return
+		}
+		// messages follow spilled ones to keep the order.
+		if isMessage && q.spill.len() > 0 && q.spillLocked(message) {
+			q.mu.Unlock()
+			return
+		}
+		if len(q.events) < q.size {
+			q.appendLocked(event)
+			q.mu.Unlock()
+			return
+		}
+
+		switch {
+		case q.policy == OverflowBlock:
+		case isMessage && q.spill != nil && q.spillLocked(message):
+			q.mu.Unlock()
+			return
+		case protected:
+			if q.evictLocked() {
+				q.appendLocked(event)
+				q.mu.Unlock()
+				return
+			}
+		case q.policy == OverflowDropNewest:
+			q.mu.Unlock()
+			q.drop(event, "queue full")
+			return
+		default:
+			if q.evictLocked() {
+				q.appendLocked(event)
+				q.mu.Unlock()
+				return
+			}
+			// the queue is full of events which are never dropped.
+			q.mu.Unlock()
+			q.drop(event, "queue full")
+			return
+		}
+
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			q.drop(event, "context done")
+			return
+		}
+	}
+}
+
+// pop returns the next event, and waits for it. It returns false when the queue is closed and empty.
+func (q *eventQueue) pop() (Event, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.events) > 0 {
+			event := q.events[0]
+			q.events[0] = nil
+			q.events = q.events[1:]
+			q.broadcastLocked()
+			q.mu.Unlock()
+			return event, true
+		}
+		if q.spill.len() > 0 {
+			event, err := q.spill.read()
+			q.mu.Unlock()
+			if err != nil {
+				q.counters.droppedMessages.Add(uint64(err.lost))
+				q.logger.Error("drop spilled messages", "count", err.lost, slog.Any("error", err.err))
+				continue
+			}
+			return event, true
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return nil, false
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		<-changed
+	}
+}
+
+// close stops accepting events. Queued events are still returned by pop.
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.broadcastLocked()
+}
+
+// release removes the spill file.
+func (q *eventQueue) release() {
+	if err := q.spill.close(); err != nil {
+		q.logger.Error("failed to remove spill file", slog.Any("error", err))
+	}
+}
+
+func (q *eventQueue) appendLocked(event Event) {
+	q.events = append(q.events, event)
+	q.broadcastLocked()
+}
+
+func (q *eventQueue) broadcastLocked() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// evictLocked drops the oldest status event, and reports whether room is made.
+func (q *eventQueue) evictLocked() bool {
+	for i, event := range q.events {
+		if !droppable(event) {
+			continue
+		}
+		q.events = append(q.events[:i], q.events[i+1:]...)
+		q.counters.dropped.Add(1)
+		q.logger.Debug("drop event", "event", fmt.Sprintf("%T", event), "reason", "queue full")
+		return true
+	}
+	return false
+}
+
+// spillLocked writes the message to the spill file, and reports whether it succeeded.
+func (q *eventQueue) spillLocked(message *MessageEvent) bool {
+	if err := q.spill.write(message); err != nil {
+		q.logger.Error("failed to spill message", "persistentID", message.PersistentID, slog.Any("error", err))
+		return false
+	}
+	q.counters.spilled.Add(1)
+	q.broadcastLocked()
+	return true
+}
+
+// drop counts the dropped event. Events which are never dropped by the policy are logged as warning.
+func (q *eventQueue) drop(event Event, reason string) {
+	switch ev := event.(type) {
+	case *MessageEvent:
+		q.counters.droppedMessages.Add(1)
+		q.logger.Warn("drop message event", "persistentID", ev.PersistentID, "reason", reason)
+	case *DecryptionFailedEvent:
+		q.counters.droppedMessages.Add(1)
+		q.logger.Warn("drop decryption failed event", "persistentID", ev.Message.PersistentID, "reason", reason)
+	case *UpdateCredentialsEvent, *UnauthorizedError, *OptionError:
+		q.counters.dropped.Add(1)
+		q.logger.Warn("drop event", "event", fmt.Sprintf("%T", event), "reason", reason)
+	default:
+		q.counters.dropped.Add(1)
+		q.logger.Debug("drop event", "event", fmt.Sprintf("%T", event), "reason", reason)
+	}
+}
+
+// droppable reports whether the event can be dropped by the overflow policy.
+// Messages and events which must be persisted or handled by the application are not droppable.
+func droppable(event Event) bool {
+	switch event.(type) {
+	case *MessageEvent, *DecryptionFailedEvent, *UpdateCredentialsEvent, *UnauthorizedError, *OptionError:
+		return false
+	default:
+		return true
+	}
+}
+
+// spillRecord is a message in the spill file.
+type spillRecord struct {
+	Seq     uint64        `json:"seq"`
+	Message *MessageEvent `json:"message"`
+}
+
+// spillError is read error of the spill file, and all spilled messages are lost.
+type spillError struct {
+	err  error
+	lost int
+}
+
+// spillFile is FIFO of messages in the file, which is truncated whenever it becomes empty.
+// Acknowledgement handles of messages are kept in memory.
+type spillFile struct {
+	file        *os.File
+	readOffset  int64
+	writeOffset int64
+	count       int
+	seq         uint64
+	acks        map[uint64]*messageAck
+}
+
+func openSpillFile(filename string) (*spillFile, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "open spill file")
+	}
+	return &spillFile{
+		file: file,
+		acks: make(map[uint64]*messageAck),
+	}, nil
+}
+
+func (s *spillFile) len() int {
+	if s == nil {
+		return 0
+	}
+	return s.count
+}
+
+func (s *spillFile) write(message *MessageEvent) error {
+	s.seq++
+	data, err := json.Marshal(spillRecord{Seq: s.seq, Message: message})
+	if err != nil {
+		return errors.Wrap(err, "marshal spilled message")
+	}
+
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+	if _, err := s.file.WriteAt(buf, s.writeOffset); err != nil {
+		return errors.Wrap(err, "write spill file")
+	}
+
+	s.writeOffset += int64(len(buf))
+	s.count++
+	if message.ack != nil {
+		s.acks[s.seq] = message.ack
+	}
+	return nil
+}
+
+func (s *spillFile) read() (*MessageEvent, *spillError) {
+	var header [4]byte
+	if _, err := s.file.ReadAt(header[:], s.readOffset); err != nil {
+		return nil, s.fail(errors.Wrap(err, "read spill file"))
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := s.file.ReadAt(data, s.readOffset+int64(len(header))); err != nil {
+		return nil, s.fail(errors.Wrap(err, "read spill file"))
+	}
+	s.readOffset += int64(len(header) + len(data))
+	s.count--
+
+	var record spillRecord
+	err := json.Unmarshal(data, &record)
+	ack := s.acks[record.Seq]
+	delete(s.acks, record.Seq)
+	if s.count == 0 {
+		s.reset()
+	}
+	if err != nil {
+		return nil, &spillError{err: errors.Wrap(err, "unmarshal spilled message"), lost: 1}
+	}
+	if record.Message == nil {
+		return nil, &spillError{err: errors.New("spilled message is empty"), lost: 1}
+	}
+	record.Message.ack = ack
+	return record.Message, nil
+}
+
+// fail discards all spilled messages.
+func (s *spillFile) fail(err error) *spillError {
+	lost := s.count
+	s.count = 0
+	clear(s.acks)
+	s.reset()
+	return &spillError{err: err, lost: lost}
+}
+
+// reset rewinds the empty file. Records are counted, so stale bytes are never read even if truncation fails.
+func (s *spillFile) reset() {
+	s.readOffset = 0
+	s.writeOffset = 0
+	_ = s.file.Truncate(0)
+}
+
+func (s *spillFile) close() error {
+	if s == nil {
+		return nil
+	}
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(s.file.Name())
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestEventQueueDropOldestKeepsProtectedEvents(t *testing.T) {
+	var counters eventCounters
+	q, err := newEventQueue(3, OverflowDropOldest, "", slog.New(noOpHandler{}), &counters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	q.push(ctx, &UpdateCredentialsEvent{})
+	q.push(ctx, &HeartbeatEvent{})
+	q.push(ctx, &DecryptionFailedEvent{Message: &MessageEvent{}})
+	q.push(ctx, &UnauthorizedError{})
+	q.push(ctx, &HeartbeatEvent{})
+	q.close()
+
+	var got []string
+	for {
+		event, ok := q.pop()
+		if !ok {
+			break
+		}
+		got = append(got, fmt.Sprintf("%T", event))
+	}
+	want := []string{"*pushreceiver.UpdateCredentialsEvent", "*pushreceiver.DecryptionFailedEvent", "*pushreceiver.UnauthorizedError"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if dropped := counters.dropped.Load(); dropped != 2 {
+		t.Fatalf("dropped = %d, want 2", dropped)
+	}
+}
+
+func TestEventQueueDefaultNeverBlocksStatusEvents(t *testing.T) {
+	if policy := New(&Config{}).overflowPolicy; policy != OverflowDropOldest {
+		t.Fatalf("default policy = %s", policy)
+	}
+
+	var counters eventCounters
+	q, err := newEventQueue(2, OverflowDropOldest, "", slog.New(noOpHandler{}), &counters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	q.push(ctx, &MessageEvent{PersistentID: "a"})
+	q.push(ctx, &MessageEvent{PersistentID: "b"})
+	// the queue is full of messages, status events are dropped without waiting.
+	q.push(ctx, &HeartbeatEvent{})
+	q.push(ctx, &DisconnectedEvent{})
+	if dropped := counters.dropped.Load(); dropped != 2 {
+		t.Fatalf("dropped = %d, want 2", dropped)
+	}
+}
+
+func TestEventQueueSpillKeepsOrder(t *testing.T) {
+	var counters eventCounters
+	q, err := newEventQueue(2, OverflowSpill, filepath.Join(t.TempDir(), "spill"), slog.New(noOpHandler{}), &counters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.release()
+	ctx := context.Background()
+
+	var want []string
+	for i := range 10 {
+		id := fmt.Sprint(i)
+		want = append(want, id)
+		q.push(ctx, &MessageEvent{PersistentID: id, ack: newMessageAck(func() {})})
+	}
+	q.close()
+
+	var got []string
+	for {
+		event, ok := q.pop()
+		if !ok {
+			break
+		}
+		message := event.(*MessageEvent)
+		if message.ack == nil {
+			t.Fatalf("ack of message %s is lost", message.PersistentID)
+		}
+		got = append(got, message.PersistentID)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("messages = %v, want %v", got, want)
+	}
+	if spilled := counters.spilled.Load(); spilled != 8 {
+		t.Fatalf("spilled = %d, want 8", spilled)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// refreshTokenLoop refreshes the registration token periodically.
+func (c *Client) refreshTokenLoop(ctx context.Context) {
+	wait := c.nextTokenRefresh()
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := c.refreshToken(ctx); err != nil {
+			c.logger.Warn("failed to refresh registration token", slog.Any("error", err))
+			wait = min(c.tokenRefreshPeriod, tokenRefreshRetryInterval*time.Minute)
+			continue
+		}
+		wait = c.nextTokenRefresh()
+	}
+}
+
+// nextTokenRefresh returns duration until next registration token refresh of the credentials or additional apps.
+func (c *Client) nextTokenRefresh() time.Duration {
+	creds := c.credentials()
+	if creds == nil {
+		return c.tokenRefreshPeriod
+	}
+	wait := c.tokenRefreshWait(creds)
+	for _, app := range creds.Apps {
+		wait = min(wait, c.tokenRefreshWait(app))
+	}
+	return wait
+}
+
+// tokenRefreshWait returns duration until the registration token of the credentials should be refreshed.
+func (c *Client) tokenRefreshWait(creds *FCMCredentials) time.Duration {
+	if creds.TokenUpdatedAt.IsZero() {
+		return c.tokenRefreshPeriod
+	}
+	return max(time.Until(creds.TokenUpdatedAt.Add(c.tokenRefreshPeriod)), 0)
+}
+
+// tokenRefreshDue reports whether the registration token of the credentials should be refreshed now.
+// Tokens without update time are refreshed with the others.
+func (c *Client) tokenRefreshDue(creds *FCMCredentials) bool {
+	return creds.TokenUpdatedAt.IsZero() || !time.Now().Before(creds.TokenUpdatedAt.Add(c.tokenRefreshPeriod))
+}
+
+// refreshToken revalidates the registration tokens with current key pair, and rotates them.
+// The primary token and tokens of additional apps are refreshed independently,
+// and only the failed ones are retried.
+func (c *Client) refreshToken(ctx context.Context) error {
+	if c.credentials() == nil {
+		// not registered yet, registration token is created by Subscribe.
+		return nil
+	}
+	err := c.refreshPrimaryToken(ctx)
+	if appErr := c.refreshAppTokens(ctx); err == nil {
+		err = appErr
+	}
+	return err
+}
+
+// refreshPrimaryToken rotates the registration token of the primary app.
+func (c *Client) refreshPrimaryToken(ctx context.Context) error {
+	old := c.credentials()
+	if old == nil || !c.tokenRefreshDue(old) {
+		return nil
+	}
+	if len(old.FID) == 0 {
+		// credentials created by older version can not be refreshed.
+		c.logger.Debug("skip registration token refresh", slog.Any("error", ErrMissingInstallation))
+		return nil
+	}
+
+	creds := *old
+	if _, err := c.ensureAuthToken(ctx, &creds); err != nil {
+		return errors.Wrap(err, "generate auth token")
+	}
+	token, err := c.updateFCM(ctx, &creds)
+	if err != nil {
+		return err
+	}
+	creds.Token = token
+	creds.TokenUpdatedAt = time.Now()
+
+	if !c.swapCredentials(old, &creds) {
+		// credentials are registered again while refreshing.
+		return nil
+	}
+	c.saveCredentials(ctx, &creds)
+	c.emit(ctx, &UpdateCredentialsEvent{Credentials: &creds, OldToken: old.Token})
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// httpClientFunc is httpClient implemented by function.
+type httpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestRefreshTokenIndependently(t *testing.T) {
+	// tokens of project "broken" are not updated until fixed.
+	broken := true
+	client := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPatch {
+			t.Errorf("unexpected request: %s %s", req.Method, req.URL)
+			return jsonResponse(http.StatusBadRequest, "{}"), nil
+		}
+		if broken && strings.Contains(req.URL.Path, "/projects/broken/") {
+			return jsonResponse(http.StatusInternalServerError, "{}"), nil
+		}
+		return jsonResponse(http.StatusOK, `{"token":"new-`+path.Base(req.URL.Path)+`"}`), nil
+	})
+
+	stale := time.Now().Add(-48 * time.Hour)
+	expiresAt := time.Now().Add(24 * time.Hour)
+	app := &FCMCredentials{AppID: "app", Token: "app-token", FID: "app-fid", AuthToken: "app-auth", AuthTokenExpiresAt: expiresAt, TokenUpdatedAt: stale}
+	creds := &FCMCredentials{AppID: "primary", Token: "token", FID: "fid", AuthToken: "auth", AuthTokenExpiresAt: expiresAt, TokenUpdatedAt: stale, Apps: []*FCMCredentials{app}}
+
+	c := New(&Config{ProjectID: "project", AppID: "primary"},
+		WithHTTPClient(client),
+		WithCreds(creds),
+		WithApps(&Config{ProjectID: "broken", AppID: "app"}),
+		WithTokenRefresh(24*time.Hour),
+	)
+	nextEvent := queuedEvents(t, c)
+
+	// the primary rotation is committed even though the app fails.
+	if err := c.refreshToken(context.Background()); err == nil {
+		t.Fatal("error of the app is not returned")
+	}
+	updated := c.credentials()
+	if updated.Token != "new-token" {
+		t.Fatalf("primary token = %s, want new-token", updated.Token)
+	}
+	if updated.Apps[0] != app {
+		t.Fatalf("failed app is changed: %+v", updated.Apps[0])
+	}
+	event := nextEvent().(*UpdateCredentialsEvent)
+	if event.OldToken != "token" || event.Credentials.Token != "new-token" {
+		t.Fatalf("event = %+v", event)
+	}
+	if wait := c.nextTokenRefresh(); wait != 0 {
+		t.Fatalf("next refresh of the failed app = %v, want 0", wait)
+	}
+
+	// only the failed app is retried.
+	broken = false
+	if err := c.refreshToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	updated = c.credentials()
+	if updated.Token != "new-token" {
+		t.Fatalf("primary token is refreshed again: %s", updated.Token)
+	}
+	if updated.Apps[0].Token != "new-app-token" {
+		t.Fatalf("app token = %s, want new-app-token", updated.Apps[0].Token)
+	}
+	event = nextEvent().(*UpdateCredentialsEvent)
+	if event.Credentials.Apps[0].Token != "new-app-token" {
+		t.Fatalf("event = %+v", event)
+	}
+	if wait := c.nextTokenRefresh(); wait < 23*time.Hour {
+		t.Fatalf("next refresh = %v, want about 24h", wait)
+	}
+}
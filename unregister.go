@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2026 Zenichi Amano
+ *
+ * This file is part of go-push-receiver, which is MIT licensed.
+ * See http://opensource.org/licenses/MIT
+ */
+
+package pushreceiver
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// UnregisterResult is result of each step of Unregister.
+// Each field is nil when the step succeeded.
+type UnregisterResult struct {
+	FCMRegistration error
+	Installation    error
+	GCMRegistration error
+
+	// Apps is results of additional apps keyed by app id.
+	Apps map[string]*UnregisterResult
+}
+
+// Err returns the first error of steps.
+func (r *UnregisterResult) Err() error {
+	switch {
+	case r.FCMRegistration != nil:
+		return errors.Wrap(r.FCMRegistration, "delete FCM registration")
+	case r.Installation != nil:
+		return errors.Wrap(r.Installation, "delete Firebase installation")
+	case r.GCMRegistration != nil:
+		return errors.Wrap(r.GCMRegistration, "unregister GCM")
+	}
+	for appID, app := range r.Apps {
+		if err := app.Err(); err != nil {
+			return errors.Wrapf(err, "app %s", appID)
+		}
+	}
+	return nil
+}
+
+// Unregister deletes the FCM registration, the Firebase installation and the GCM registration of the credentials.
+// Registrations of additional apps given by WithApps are deleted as well.
+// It must not be called while Subscribe is running.
+// Credentials are discarded, and deleted from the credential store when all steps succeeded.
+func (c *Client) Unregister(ctx context.Context) (*UnregisterResult, error) {
+	if c.optionErr != nil {
+		return nil, c.optionErr
+	}
+	if err := c.loadCredentials(ctx); err != nil {
+		return nil, err
+	}
+	creds := c.credentials()
+	if creds == nil {
+		return nil, ErrNotRegistered
+	}
+
+	result := c.unregister(ctx, creds)
+	for _, app := range creds.Apps {
+		if result.Apps == nil {
+			result.Apps = make(map[string]*UnregisterResult, len(creds.Apps))
+		}
+		config, err := c.appConfig(app.AppID)
+		if err != nil {
+			result.Apps[app.AppID] = &UnregisterResult{
+				FCMRegistration: err,
+				Installation:    err,
+				GCMRegistration: err,
+			}
+			continue
+		}
+		result.Apps[app.AppID] = c.appClient(config).unregister(ctx, app)
+	}
+
+	if err := result.Err(); err != nil {
+		return result, err
+	}
+	c.setCredentials(nil)
+	if c.credentialStore != nil {
+		if err := c.credentialStore.Delete(ctx); err != nil {
+			return result, errors.Wrap(err, "delete credentials")
+		}
+	}
+	return result, nil
+}
+
+// unregister deletes registrations of the credentials.
+func (c *Client) unregister(ctx context.Context, creds *FCMCredentials) *UnregisterResult {
+	result := &UnregisterResult{}
+	if len(creds.FID) == 0 {
+		result.FCMRegistration = ErrMissingInstallation
+		result.Installation = ErrMissingInstallation
+	} else {
+		if _, err := c.ensureAuthToken(ctx, creds); err != nil {
+			result.FCMRegistration = errors.Wrap(err, "generate auth token")
+		} else {
+			result.FCMRegistration = c.deleteFCM(ctx, creds)
+		}
+		result.Installation = c.deleteInstallation(ctx, creds)
+	}
+	result.GCMRegistration = c.unregisterGCM(ctx, creds.AndroidID, creds.SecurityToken)
+
+	return result
+}